@@ -0,0 +1,253 @@
+package observer
+
+import "sync"
+
+// Policy определяет, что делать с событием, если очередь наблюдателя заполнена.
+type Policy int
+
+const (
+	// Block заставляет Notify ждать, пока в очереди не освободится место.
+	Block Policy = iota
+	// DropNewest отбрасывает новое событие.
+	DropNewest
+	// DropOldest отбрасывает самое старое событие в очереди, освобождая место для нового.
+	DropOldest
+	// Disconnect отбрасывает новое событие и удаляет наблюдателя.
+	Disconnect
+)
+
+// DefaultQueueSize - размер очереди наблюдателя, зарегистрированного через Register.
+const DefaultQueueSize = 64
+
+// AsyncNotifier - реализация Notifier, доставляющая события асинхронно:
+// у каждого наблюдателя своя ограниченная очередь и своя goroutine,
+// поэтому медленный наблюдатель не задерживает остальных.
+// Нулевое значение готово к использованию.
+type AsyncNotifier struct {
+	// OnDisconnect, если задан, вызывается для наблюдателя,
+	// удаленного из-за переполнения очереди с политикой Disconnect.
+	// dropped - итоговое число отброшенных событий наблюдателя:
+	// после отключения Dropped для него уже ничего не знает.
+	OnDisconnect func(o Observer, dropped uint64)
+
+	mu       sync.Mutex
+	queues   map[Observer]*queue
+	snapshot []*queue
+}
+
+// NewAsync возвращает новый AsyncNotifier без наблюдателей.
+func NewAsync() *AsyncNotifier {
+	return &AsyncNotifier{}
+}
+
+// Register регистрирует наблюдателя с очередью DefaultQueueSize и политикой Block.
+func (n *AsyncNotifier) Register(o Observer) {
+	n.RegisterQueue(o, DefaultQueueSize, Block)
+}
+
+// RegisterQueue регистрирует наблюдателя с очередью на size событий
+// и политикой policy на случай ее переполнения.
+// Повторная регистрация уже зарегистрированного наблюдателя ничего не меняет.
+func (n *AsyncNotifier) RegisterQueue(o Observer, size int, policy Policy) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.queues == nil {
+		n.queues = map[Observer]*queue{}
+	}
+	if _, ok := n.queues[o]; ok {
+		return
+	}
+	q := newQueue(o, size, policy)
+	n.queues[o] = q
+	n.snapshot = nil
+	go q.run()
+}
+
+// Deregister удаляет наблюдателя. Событие, которое наблюдатель обрабатывает сейчас,
+// будет обработано до конца, а оставшиеся в очереди события отбрасываются.
+// Deregister не ждет завершения goroutine наблюдателя,
+// поэтому его можно вызывать из OnNotify.
+func (n *AsyncNotifier) Deregister(o Observer) {
+	n.mu.Lock()
+	q, ok := n.queues[o]
+	if ok {
+		delete(n.queues, o)
+		n.snapshot = nil
+	}
+	n.mu.Unlock()
+	if ok {
+		q.close(false)
+	}
+}
+
+// Notify ставит событие в очереди всех наблюдателей.
+// Ждать Notify может только наблюдатель с политикой Block и заполненной очередью.
+func (n *AsyncNotifier) Notify(e Event) {
+	for _, q := range n.queueList() {
+		if !q.push(e) {
+			n.disconnect(q)
+		}
+	}
+}
+
+// Dropped возвращает число событий, отброшенных из-за переполнения очереди наблюдателя o.
+// Для незарегистрированного наблюдателя возвращает 0; итог для наблюдателя,
+// отключенного по политике Disconnect, передается в OnDisconnect.
+func (n *AsyncNotifier) Dropped(o Observer) uint64 {
+	n.mu.Lock()
+	q, ok := n.queues[o]
+	n.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.dropped
+}
+
+// Close удаляет всех наблюдателей и ждет, пока они обработают
+// уже поставленные в их очереди события. Новые события после Close не доставляются.
+// Close нельзя вызывать из OnNotify.
+func (n *AsyncNotifier) Close() {
+	n.mu.Lock()
+	queues := n.queues
+	n.queues = nil
+	n.snapshot = nil
+	n.mu.Unlock()
+	for _, q := range queues {
+		q.close(true)
+	}
+	for _, q := range queues {
+		<-q.done
+	}
+}
+
+func (n *AsyncNotifier) queueList() []*queue {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.snapshot == nil && len(n.queues) > 0 {
+		n.snapshot = make([]*queue, 0, len(n.queues))
+		for _, q := range n.queues {
+			n.snapshot = append(n.snapshot, q)
+		}
+	}
+	return n.snapshot
+}
+
+func (n *AsyncNotifier) disconnect(q *queue) {
+	n.mu.Lock()
+	current := n.queues[q.o] == q
+	if current {
+		delete(n.queues, q.o)
+		n.snapshot = nil
+	}
+	n.mu.Unlock()
+	if !current {
+		return
+	}
+	q.close(false)
+	if n.OnDisconnect != nil {
+		q.mu.Lock()
+		dropped := q.dropped
+		q.mu.Unlock()
+		n.OnDisconnect(q.o, dropped)
+	}
+}
+
+// queue - кольцевой буфер событий одного наблюдателя.
+type queue struct {
+	o      Observer
+	policy Policy
+	// done закрывается, когда goroutine наблюдателя завершилась.
+	done chan struct{}
+
+	mu       sync.Mutex
+	notEmpty sync.Cond
+	notFull  sync.Cond
+	buf      []Event
+	head     int
+	n        int
+	closed   bool
+	// drain - после закрытия доставить оставшиеся в очереди события.
+	drain   bool
+	dropped uint64
+}
+
+func newQueue(o Observer, size int, policy Policy) *queue {
+	if size < 1 {
+		size = 1
+	}
+	q := &queue{
+		o:      o,
+		policy: policy,
+		done:   make(chan struct{}),
+		buf:    make([]Event, size),
+	}
+	q.notEmpty.L = &q.mu
+	q.notFull.L = &q.mu
+	return q
+}
+
+// push добавляет событие в очередь согласно политике.
+// Возвращает false, если наблюдателя нужно отключить.
+func (q *queue) push(e Event) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.n == len(q.buf) && !q.closed {
+		switch q.policy {
+		case DropNewest:
+			q.dropped++
+			return true
+		case DropOldest:
+			q.head = (q.head + 1) % len(q.buf)
+			q.n--
+			q.dropped++
+		case Disconnect:
+			q.dropped++
+			return false
+		default:
+			q.notFull.Wait()
+		}
+	}
+	if q.closed {
+		return true
+	}
+	q.buf[(q.head+q.n)%len(q.buf)] = e
+	q.n++
+	q.notEmpty.Signal()
+	return true
+}
+
+// run доставляет события наблюдателю, пока очередь не будет закрыта.
+func (q *queue) run() {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		for q.n == 0 && !q.closed {
+			q.notEmpty.Wait()
+		}
+		if q.closed && (!q.drain || q.n == 0) {
+			q.mu.Unlock()
+			return
+		}
+		e := q.buf[q.head]
+		q.buf[q.head] = Event{}
+		q.head = (q.head + 1) % len(q.buf)
+		q.n--
+		q.notFull.Signal()
+		q.mu.Unlock()
+
+		q.o.OnNotify(e)
+	}
+}
+
+// close закрывает очередь. С drain goroutine наблюдателя сначала доставит
+// оставшиеся события, иначе они отбрасываются.
+func (q *queue) close(drain bool) {
+	q.mu.Lock()
+	q.closed = true
+	q.drain = drain
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mu.Unlock()
+}
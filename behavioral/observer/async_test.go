@@ -0,0 +1,71 @@
+package observer
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// gate - наблюдатель, который не обрабатывает события, пока не открыт release.
+type gate struct {
+	release chan struct{}
+	mu      sync.Mutex
+	got     []int64
+}
+
+func (g *gate) OnNotify(e Event) {
+	<-g.release
+	g.mu.Lock()
+	g.got = append(g.got, e.Data)
+	g.mu.Unlock()
+}
+
+func TestAsyncDisconnectReportsDropped(t *testing.T) {
+	n := NewAsync()
+	type disconnect struct {
+		o       Observer
+		dropped uint64
+	}
+	disconnected := make(chan disconnect, 1)
+	n.OnDisconnect = func(o Observer, dropped uint64) { disconnected <- disconnect{o, dropped} }
+
+	g := &gate{release: make(chan struct{})}
+	n.RegisterQueue(g, 1, Disconnect)
+	// Первое событие забирает goroutine наблюдателя, второе заполняет очередь.
+	n.Notify(Event{Data: 1})
+	time.Sleep(10 * time.Millisecond)
+	n.Notify(Event{Data: 2})
+	n.Notify(Event{Data: 3})
+
+	d := <-disconnected
+	if d.o != g || d.dropped != 1 {
+		t.Fatalf("OnDisconnect(%v, %d), ожидалось (%v, 1)", d.o, d.dropped, g)
+	}
+	close(g.release)
+	n.Close()
+}
+
+func TestAsyncCloseDrainsQueues(t *testing.T) {
+	n := NewAsync()
+	g := &gate{release: make(chan struct{})}
+	n.RegisterQueue(g, 8, Block)
+	for i := int64(1); i <= 5; i++ {
+		n.Notify(Event{Data: i})
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		n.Close()
+		close(closed)
+	}()
+	close(g.release)
+	<-closed
+
+	if len(g.got) != 5 {
+		t.Fatalf("после Close доставлено %v, ожидались все 5 событий", g.got)
+	}
+	n.Notify(Event{Data: 6})
+	if len(g.got) != 5 {
+		t.Fatal("событие после Close доставлено")
+	}
+}
@@ -0,0 +1,480 @@
+package observer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSegmentSize - размер сегмента журнала, после которого начинается новый сегмент.
+	DefaultSegmentSize = 64 << 20
+
+	segmentExt = ".seg"
+	ackExt     = ".ack"
+	// segmentNameLen - число цифр в имени сегмента: номер первого события с ведущими нулями.
+	segmentNameLen = 20
+
+	// Запись журнала: длина данных (4 байта), CRC-32C данных (4 байта) и данные:
+	// Seq (8 байт), Time в наносекундах Unix (8 байт), Data (8 байт).
+	recordHeaderSize  = 8
+	recordPayloadSize = 24
+	recordSize        = recordHeaderSize + recordPayloadSize
+)
+
+var (
+	ErrLogClosed   = errors.New("журнал событий закрыт")
+	ErrBadConsumer = errors.New("недопустимое имя потребителя журнала")
+
+	errTornRecord = errors.New("запись журнала повреждена")
+	errStopScan   = errors.New("чтение журнала остановлено")
+	crc32cTable   = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// Log - Notifier, который перед доставкой записывает каждое событие
+// в сегментированный журнал только для добавления, поэтому события переживают перезапуск.
+//
+// Потребитель регистрируется под постоянным именем через RegisterDurable
+// и подтверждает обработанные события через Ack. После перезапуска
+// ему заново доставляются все события после последнего подтвержденного,
+// то есть доставка выполняется как минимум один раз (at-least-once).
+type Log struct {
+	n           *EventNotifier
+	dir         string
+	segmentSize int64
+
+	mu sync.Mutex
+	// segments - номера первых событий сегментов по возрастанию.
+	segments []uint64
+	active   *os.File
+	size     int64
+	seq      uint64
+	closed   bool
+	// dispatch доставляет события наблюдателям в порядке Seq.
+	dispatch dispatcher
+	// replayers - обертки наблюдателей, зарегистрированных с воспроизведением журнала.
+	replayers map[Observer]*replayer
+
+	// ackMu защищает acks - блокировки, по одной на потребителя,
+	// которые упорядочивают сохранение его подтверждений.
+	ackMu sync.Mutex
+	acks  map[string]*sync.Mutex
+}
+
+// OpenLog открывает журнал в каталоге dir, создавая его при необходимости, и доставляет события через n.
+// Если последняя запись журнала была записана не полностью, например из-за падения процесса,
+// журнал обрезается до последней целой записи.
+// segmentSize равный нулю означает DefaultSegmentSize. Если n равен nil, создается новый EventNotifier.
+func OpenLog(dir string, n *EventNotifier, segmentSize int64) (*Log, error) {
+	if n == nil {
+		n = New()
+	}
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("не могу создать каталог журнала: %w", err)
+	}
+	l := &Log{
+		n:           n,
+		dir:         dir,
+		segmentSize: segmentSize,
+		replayers:   map[Observer]*replayer{},
+	}
+	if err := l.restore(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Register регистрирует наблюдателя только на новые события.
+func (l *Log) Register(o Observer) {
+	l.n.Register(o)
+}
+
+func (l *Log) Deregister(o Observer) {
+	l.mu.Lock()
+	r, ok := l.replayers[o]
+	delete(l.replayers, o)
+	l.mu.Unlock()
+	if ok {
+		l.n.Deregister(r)
+		return
+	}
+	l.n.Deregister(o)
+}
+
+// Notify записывает событие в журнал и доставляет его наблюдателям.
+// Если записать событие не удалось, оно не доставляется, а ошибка записывается
+// в стандартный логгер. Чтобы обработать ошибку самостоятельно, используйте Publish.
+func (l *Log) Notify(e Event) {
+	if _, err := l.Publish(e); err != nil {
+		log.Printf("событие не опубликовано: %v", err)
+	}
+}
+
+// Publish присваивает событию очередные Seq и Time (если Time не задано),
+// записывает его в журнал и только после этого доставляет наблюдателям.
+// Возвращает событие в том виде, в каком оно записано.
+// Как и у History, события доставляются строго в порядке Seq, поэтому при одновременной
+// публикации из нескольких goroutine Publish может вернуться раньше, чем событие доставлено.
+func (l *Log) Publish(e Event) (Event, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return e, ErrLogClosed
+	}
+	e.Seq = l.seq + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if err := l.append(e); err != nil {
+		l.mu.Unlock()
+		return e, err
+	}
+	l.seq = e.Seq
+	l.dispatch.push(e)
+	l.mu.Unlock()
+
+	l.dispatch.run(l.n.Notify)
+	return e, nil
+}
+
+// RegisterAfter регистрирует наблюдателя и сначала доставляет ему из журнала события
+// с Seq больше seq, а затем новые события - без пропусков и повторов.
+// События из журнала доставляются в вызывающей goroutine до возврата из RegisterAfter.
+func (l *Log) RegisterAfter(o Observer, seq uint64) error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return ErrLogClosed
+	}
+	upto := l.seq
+	segments := append([]uint64(nil), l.segments...)
+	last := seq
+	if last > upto {
+		last = upto
+	}
+	r := &replayer{o: o, last: last, replaying: true}
+	if old, ok := l.replayers[o]; ok {
+		l.n.Deregister(old)
+	}
+	l.replayers[o] = r
+	// Регистрация под l.mu гарантирует, что каждое событие после upto
+	// будет доставлено r через Notify.
+	l.n.Register(r)
+	l.mu.Unlock()
+
+	// Записи с номерами не больше upto уже целиком в файлах и не меняются,
+	// поэтому их можно читать без блокировки.
+	err := l.read(segments, seq, upto, r.deliver)
+	r.catchUp()
+	return err
+}
+
+// RegisterDurable регистрирует наблюдателя как потребителя с именем name
+// и доставляет ему все события после последнего подтвержденного этим потребителем.
+func (l *Log) RegisterDurable(name string, o Observer) error {
+	seq, err := l.Acked(name)
+	if err != nil {
+		return err
+	}
+	return l.RegisterAfter(o, seq)
+}
+
+// Ack сохраняет seq как последнее обработанное потребителем name событие.
+func (l *Log) Ack(name string, seq uint64) error {
+	path, err := l.ackPath(name)
+	if err != nil {
+		return err
+	}
+	mu := l.ackLock(name)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Пишем в уникальный временный файл, сбрасываем его на диск и переименовываем,
+	// а затем сбрасываем каталог, чтобы после сбоя питания остаться
+	// либо со старым, либо с новым номером, но не с пустым файлом.
+	f, err := os.CreateTemp(l.dir, name+ackExt+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("не могу сохранить подтверждение: %w", err)
+	}
+	_, err = f.WriteString(strconv.FormatUint(seq, 10))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err == nil {
+		err = syncDir(l.dir)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("не могу сохранить подтверждение: %w", err)
+	}
+	return nil
+}
+
+// ackLock возвращает блокировку подтверждений потребителя name.
+func (l *Log) ackLock(name string) *sync.Mutex {
+	l.ackMu.Lock()
+	defer l.ackMu.Unlock()
+	if l.acks == nil {
+		l.acks = map[string]*sync.Mutex{}
+	}
+	mu, ok := l.acks[name]
+	if !ok {
+		mu = &sync.Mutex{}
+		l.acks[name] = mu
+	}
+	return mu
+}
+
+// syncDir сбрасывает на диск каталог dir, чтобы созданные и переименованные в нем файлы
+// пережили сбой питания.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Acked возвращает последнее подтвержденное потребителем name событие
+// или 0, если потребитель еще ничего не подтверждал.
+func (l *Log) Acked(name string) (uint64, error) {
+	path, err := l.ackPath(name)
+	if err != nil {
+		return 0, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("не могу прочитать подтверждение: %w", err)
+	}
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("подтверждение потребителя %q повреждено: %w", name, err)
+	}
+	return seq, nil
+}
+
+// Close закрывает журнал. Последующие Publish возвращают ErrLogClosed.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	return l.active.Close()
+}
+
+func (l *Log) ackPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrBadConsumer, name)
+	}
+	return filepath.Join(l.dir, name+ackExt), nil
+}
+
+func (l *Log) segmentPath(first uint64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", first, segmentExt))
+}
+
+// restore находит сегменты журнала, обрезает поврежденный хвост последнего сегмента
+// и открывает его для записи.
+func (l *Log) restore() error {
+	names, err := filepath.Glob(filepath.Join(l.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		base := strings.TrimSuffix(filepath.Base(name), segmentExt)
+		if len(base) != segmentNameLen {
+			continue
+		}
+		first, err := strconv.ParseUint(base, 10, 64)
+		if err != nil {
+			continue
+		}
+		l.segments = append(l.segments, first)
+	}
+	sort.Slice(l.segments, func(i, j int) bool { return l.segments[i] < l.segments[j] })
+	if len(l.segments) == 0 {
+		return l.openSegment(1)
+	}
+
+	first := l.segments[len(l.segments)-1]
+	f, err := os.OpenFile(l.segmentPath(first), os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("не могу открыть сегмент журнала: %w", err)
+	}
+	l.seq = first - 1
+	var good int64
+	err = scan(f, func(e Event) error {
+		if e.Seq != l.seq+1 {
+			return errTornRecord
+		}
+		l.seq = e.Seq
+		good += recordSize
+		return nil
+	})
+	if err != nil && !errors.Is(err, errTornRecord) {
+		f.Close()
+		return fmt.Errorf("не могу прочитать сегмент журнала: %w", err)
+	}
+	// Все после последней целой записи - результат прерванной записи.
+	if err := f.Truncate(good); err != nil {
+		f.Close()
+		return fmt.Errorf("не могу обрезать сегмент журнала: %w", err)
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	l.active = f
+	l.size = good
+	return nil
+}
+
+// openSegment создает новый активный сегмент, начинающийся с события first. Вызывается под l.mu.
+func (l *Log) openSegment(first uint64) error {
+	f, err := os.OpenFile(l.segmentPath(first), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("не могу создать сегмент журнала: %w", err)
+	}
+	// Без сброса каталога новый сегмент может пропасть после сбоя питания
+	// вместе с уже подтвержденными записями.
+	if err := syncDir(l.dir); err != nil {
+		f.Close()
+		os.Remove(l.segmentPath(first))
+		return fmt.Errorf("не могу создать сегмент журнала: %w", err)
+	}
+	if l.active != nil {
+		l.active.Close()
+	}
+	l.active = f
+	l.size = 0
+	l.segments = append(l.segments, first)
+	return nil
+}
+
+// append записывает событие в активный сегмент и сбрасывает его на диск. Вызывается под l.mu.
+func (l *Log) append(e Event) error {
+	if l.size >= l.segmentSize {
+		if err := l.openSegment(e.Seq); err != nil {
+			return err
+		}
+	}
+	var buf [recordSize]byte
+	payload := buf[recordHeaderSize:]
+	binary.LittleEndian.PutUint64(payload[0:], e.Seq)
+	binary.LittleEndian.PutUint64(payload[8:], uint64(e.Time.UnixNano()))
+	binary.LittleEndian.PutUint64(payload[16:], uint64(e.Data))
+	binary.LittleEndian.PutUint32(buf[0:], recordPayloadSize)
+	binary.LittleEndian.PutUint32(buf[4:], crc32.Checksum(payload, crc32cTable))
+
+	_, err := l.active.Write(buf[:])
+	if err == nil {
+		err = l.active.Sync()
+	}
+	if err != nil {
+		// Убираем частичную или не сброшенную на диск запись, чтобы следующая
+		// легла на ее место, а не оказалась второй записью с тем же Seq.
+		l.active.Truncate(l.size)
+		l.active.Seek(l.size, io.SeekStart)
+		return fmt.Errorf("не могу записать событие в журнал: %w", err)
+	}
+	l.size += recordSize
+	return nil
+}
+
+// read передает fn события из segments с номерами в полуинтервале (after, upto].
+func (l *Log) read(segments []uint64, after, upto uint64, fn func(Event)) error {
+	if after >= upto {
+		return nil
+	}
+	// Начинаем с последнего сегмента, первое событие которого не позже after+1.
+	start := sort.Search(len(segments), func(i int) bool { return segments[i] > after+1 }) - 1
+	if start < 0 {
+		start = 0
+	}
+	for _, first := range segments[start:] {
+		if first > upto {
+			return nil
+		}
+		f, err := os.Open(l.segmentPath(first))
+		if err != nil {
+			return fmt.Errorf("не могу открыть сегмент журнала: %w", err)
+		}
+		err = scan(f, func(e Event) error {
+			if e.Seq > upto {
+				return errStopScan
+			}
+			if e.Seq > after {
+				fn(e)
+			}
+			return nil
+		})
+		f.Close()
+		if errors.Is(err, errStopScan) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("не могу прочитать сегмент журнала: %w", err)
+		}
+	}
+	return nil
+}
+
+// scan читает записи из r и передает события fn, пока fn не вернет ошибку.
+// Неполная или поврежденная запись дает errTornRecord.
+func scan(r io.Reader, fn func(Event) error) error {
+	br := bufio.NewReader(r)
+	var buf [recordSize]byte
+	for {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			if err == io.ErrUnexpectedEOF {
+				return errTornRecord
+			}
+			return err
+		}
+		payload := buf[recordHeaderSize:]
+		if binary.LittleEndian.Uint32(buf[0:]) != recordPayloadSize ||
+			binary.LittleEndian.Uint32(buf[4:]) != crc32.Checksum(payload, crc32cTable) {
+			return errTornRecord
+		}
+		e := Event{
+			Seq:  binary.LittleEndian.Uint64(payload[0:]),
+			Time: time.Unix(0, int64(binary.LittleEndian.Uint64(payload[8:]))),
+			Data: int64(binary.LittleEndian.Uint64(payload[16:])),
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package observer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func openTestLog(t *testing.T, dir string) *Log {
+	t.Helper()
+	l, err := OpenLog(dir, nil, 10*recordSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestLogConcurrentPublishDurable(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	const goroutines, iterations = 8, 100
+	dir := t.TempDir()
+	l := openTestLog(t, dir)
+
+	// Потребитель подтверждает каждое полученное событие, как только получил его.
+	c := &seqRecorder{}
+	var ackErr error
+	var once sync.Once
+	consumer := ObserverFunc(func(e Event) {
+		c.OnNotify(e)
+		if err := l.Ack("c", e.Seq); err != nil {
+			once.Do(func() { ackErr = err })
+		}
+	})
+	if err := l.RegisterDurable("c", consumer); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				if _, err := l.Publish(Event{Data: int64(i)}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if ackErr != nil {
+		t.Fatal(ackErr)
+	}
+	// Подтвержденный номер означает, что все предыдущие события тоже получены.
+	c.checkGapless(t, goroutines*iterations)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogResumeAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir)
+	for i := 0; i < 25; i++ {
+		if _, err := l.Publish(Event{Data: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Ack("c", 7); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l = openTestLog(t, dir)
+	defer l.Close()
+	r := &seqRecorder{}
+	if err := l.RegisterDurable("c", r); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := l.Publish(Event{}); err != nil || e.Seq != 26 {
+		t.Fatalf("Publish после перезапуска: %+v, %v", e, err)
+	}
+	if len(r.seqs) != 19 || r.seqs[0] != 8 || r.seqs[18] != 26 {
+		t.Fatalf("после перезапуска получены %v, ожидались 8..26", r.seqs)
+	}
+}
+
+func TestLogTruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir)
+	for i := 0; i < 3; i++ {
+		l.Publish(Event{Data: int64(i)})
+	}
+	path := l.segmentPath(1)
+	l.Close()
+
+	// Дописываем половину записи, как при падении во время записи.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(make([]byte, recordSize/2))
+	f.Close()
+
+	l = openTestLog(t, dir)
+	defer l.Close()
+	if e, err := l.Publish(Event{}); err != nil || e.Seq != 4 {
+		t.Fatalf("Publish после обрезки: %+v, %v", e, err)
+	}
+	r := &seqRecorder{}
+	if err := l.RegisterAfter(r, 0); err != nil {
+		t.Fatal(err)
+	}
+	r.checkGapless(t, 4)
+}
+
+func TestLogConcurrentAck(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	dir := t.TempDir()
+	l := openTestLog(t, dir)
+	defer l.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := l.Ack("c", uint64(g*100+i)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	seq, err := l.Acked("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq%100 != 49 {
+		t.Fatalf("сохранено подтверждение %d, ожидалось последнее подтверждение одной из goroutine", seq)
+	}
+	tmp, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if err != nil || len(tmp) != 0 {
+		t.Fatalf("остались временные файлы: %v", tmp)
+	}
+}
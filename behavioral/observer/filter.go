@@ -0,0 +1,62 @@
+package observer
+
+import "sync/atomic"
+
+// Filter - предикат над событием. EventNotifier доставляет событие наблюдателю,
+// зарегистрированному с фильтром, только если фильтр вернул true.
+// Фильтр может вызываться из нескольких goroutine одновременно.
+type Filter func(Event) bool
+
+// And возвращает фильтр, пропускающий событие, только если его пропускают все fs.
+// And без аргументов пропускает все события.
+func And(fs ...Filter) Filter {
+	return func(e Event) bool {
+		for _, f := range fs {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or возвращает фильтр, пропускающий событие, если его пропускает хотя бы один из fs.
+// Or без аргументов не пропускает ни одного события.
+func Or(fs ...Filter) Filter {
+	return func(e Event) bool {
+		for _, f := range fs {
+			if f(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not возвращает фильтр, пропускающий события, которые не пропускает f.
+func Not(f Filter) Filter {
+	return func(e Event) bool {
+		return !f(e)
+	}
+}
+
+// DataRange пропускает события, у которых Data лежит в отрезке [min, max].
+func DataRange(min, max int64) Filter {
+	return func(e Event) bool {
+		return e.Data >= min && e.Data <= max
+	}
+}
+
+// EveryNth пропускает каждое n-е проверенное событие, начиная с первого.
+// Нулевое n равносильно единице.
+// Фильтр хранит счетчик, поэтому один и тот же экземпляр не стоит
+// использовать для нескольких наблюдателей, если выборка нужна каждому.
+func EveryNth(n uint64) Filter {
+	if n == 0 {
+		n = 1
+	}
+	var seen atomic.Uint64
+	return func(Event) bool {
+		return (seen.Add(1)-1)%n == 0
+	}
+}
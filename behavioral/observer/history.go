@@ -0,0 +1,281 @@
+package observer
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrHistoryTruncated сообщает, что часть запрошенных событий уже вытеснена из истории.
+var ErrHistoryTruncated = errors.New("часть запрошенных событий уже удалена из истории")
+
+// History - Notifier, хранящий ограниченную историю событий поверх EventNotifier.
+// Каждому событию присваиваются Seq и Time, а наблюдатели, зарегистрированные позже,
+// могут получить пропущенные события через RegisterAfter или RegisterSince.
+type History struct {
+	n      *EventNotifier
+	maxLen int
+	maxAge time.Duration
+
+	mu     sync.Mutex
+	events []Event
+	seq    uint64
+	// evicted - наибольшее Time среди вытесненных событий.
+	evicted time.Time
+	// dispatch доставляет события наблюдателям в порядке Seq.
+	dispatch dispatcher
+	// replayers - обертки наблюдателей, зарегистрированных с воспроизведением истории.
+	replayers map[Observer]*replayer
+}
+
+// NewHistory возвращает History, доставляющий события через n.
+// История хранит не больше maxLen событий, не старше maxAge.
+// Нулевое ограничение означает, что по этому признаку события не вытесняются.
+// Если n равен nil, создается новый EventNotifier.
+func NewHistory(n *EventNotifier, maxLen int, maxAge time.Duration) *History {
+	if n == nil {
+		n = New()
+	}
+	return &History{
+		n:         n,
+		maxLen:    maxLen,
+		maxAge:    maxAge,
+		replayers: map[Observer]*replayer{},
+	}
+}
+
+// Register регистрирует наблюдателя только на новые события.
+func (h *History) Register(o Observer) {
+	h.n.Register(o)
+}
+
+// RegisterAfter регистрирует наблюдателя и сначала доставляет ему события из истории
+// с Seq больше seq, а затем новые события - без пропусков и повторов.
+// Если часть этих событий уже вытеснена из истории, наблюдатель все равно регистрируется,
+// а RegisterAfter возвращает ErrHistoryTruncated.
+// События из истории доставляются в вызывающей goroutine до возврата из RegisterAfter.
+func (h *History) RegisterAfter(o Observer, seq uint64) error {
+	return h.register(o, func(e Event) bool {
+		return e.Seq > seq
+	}, func() bool {
+		// Вытеснено хотя бы одно событие с Seq больше seq.
+		return seq < h.seq && (len(h.events) == 0 || h.events[0].Seq > seq+1)
+	})
+}
+
+// RegisterSince работает как RegisterAfter, но воспроизводит события,
+// опубликованные не раньше t.
+func (h *History) RegisterSince(o Observer, t time.Time) error {
+	return h.register(o, func(e Event) bool {
+		return !e.Time.Before(t)
+	}, func() bool {
+		return !h.evicted.IsZero() && !h.evicted.Before(t)
+	})
+}
+
+func (h *History) Deregister(o Observer) {
+	h.mu.Lock()
+	r, ok := h.replayers[o]
+	delete(h.replayers, o)
+	h.mu.Unlock()
+	if ok {
+		h.n.Deregister(r)
+		return
+	}
+	h.n.Deregister(o)
+}
+
+// Notify присваивает событию очередные Seq и Time (если Time не задано),
+// сохраняет его в истории и доставляет наблюдателям.
+// События доставляются строго в порядке Seq: если их одновременно публикуют
+// несколько goroutine, доставку выполняет одна из них, и Notify может вернуться
+// раньше, чем его событие доставлено.
+func (h *History) Notify(e Event) {
+	h.mu.Lock()
+	h.seq++
+	e.Seq = h.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	h.events = append(h.events, e)
+	h.trim(e.Time)
+	h.dispatch.push(e)
+	h.mu.Unlock()
+
+	h.dispatch.run(h.n.Notify)
+}
+
+// Events возвращает копию хранимой истории.
+func (h *History) Events() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.trim(time.Now())
+	return append([]Event(nil), h.events...)
+}
+
+// register регистрирует наблюдателя с воспроизведением событий, начиная с первого,
+// подходящего под match. lost сообщает, были ли вытеснены подходящие события,
+// и вызывается под h.mu.
+func (h *History) register(o Observer, match func(Event) bool, lost func() bool) error {
+	h.mu.Lock()
+	h.trim(time.Now())
+	var (
+		replay []Event
+		err    error
+	)
+	for i, e := range h.events {
+		if match(e) {
+			replay = append(replay, h.events[i:]...)
+			break
+		}
+	}
+	if lost() {
+		err = ErrHistoryTruncated
+	}
+	// Последний известный номер: все события с меньшими номерами
+	// либо попадут в replay, либо наблюдателю не нужны.
+	last := h.seq - uint64(len(replay))
+	r := &replayer{o: o, last: last, replaying: true}
+	if old, ok := h.replayers[o]; ok {
+		h.n.Deregister(old)
+	}
+	h.replayers[o] = r
+	// Регистрация под h.mu гарантирует, что каждое следующее событие
+	// либо уже есть в replay, либо будет доставлено r через Notify.
+	h.n.Register(r)
+	h.mu.Unlock()
+
+	r.replay(replay)
+	return err
+}
+
+// trim вытесняет события сверх maxLen и старше maxAge. Вызывается под h.mu.
+func (h *History) trim(now time.Time) {
+	drop := 0
+	if h.maxLen > 0 && len(h.events) > h.maxLen {
+		drop = len(h.events) - h.maxLen
+	}
+	if h.maxAge > 0 {
+		for drop < len(h.events) && now.Sub(h.events[drop].Time) > h.maxAge {
+			drop++
+		}
+	}
+	if drop > 0 {
+		for _, e := range h.events[:drop] {
+			if e.Time.After(h.evicted) {
+				h.evicted = e.Time
+			}
+		}
+		clear(h.events[:drop])
+		h.events = h.events[drop:]
+	}
+}
+
+// replayer - обертка наблюдателя, которая сначала доставляет ему события из истории,
+// а пришедшие за это время новые события откладывает и доставляет после.
+// События с Seq не больше последнего доставленного отбрасываются,
+// поэтому наблюдатель не получает повторов.
+type replayer struct {
+	o Observer
+
+	mu        sync.Mutex
+	replaying bool
+	pending   []Event
+	last      uint64
+}
+
+func (r *replayer) OnNotify(e Event) {
+	r.mu.Lock()
+	if r.replaying {
+		r.pending = append(r.pending, e)
+		r.mu.Unlock()
+		return
+	}
+	if e.Seq <= r.last {
+		r.mu.Unlock()
+		return
+	}
+	r.last = e.Seq
+	r.mu.Unlock()
+	r.o.OnNotify(e)
+}
+
+// replay доставляет events, затем отложенные события, и переключает r на прямую доставку.
+func (r *replayer) replay(events []Event) {
+	for _, e := range events {
+		r.deliver(e)
+	}
+	r.catchUp()
+}
+
+// deliver доставляет событие из истории, если наблюдатель его еще не получал.
+func (r *replayer) deliver(e Event) {
+	r.mu.Lock()
+	fresh := e.Seq > r.last
+	if fresh {
+		r.last = e.Seq
+	}
+	r.mu.Unlock()
+	if fresh {
+		r.o.OnNotify(e)
+	}
+}
+
+// catchUp доставляет события, отложенные во время воспроизведения,
+// и переключает r на прямую доставку.
+func (r *replayer) catchUp() {
+	for {
+		r.mu.Lock()
+		if len(r.pending) == 0 {
+			r.replaying = false
+			r.mu.Unlock()
+			return
+		}
+		events := r.pending
+		r.pending = nil
+		r.mu.Unlock()
+
+		for _, e := range events {
+			r.deliver(e)
+		}
+	}
+}
+
+// dispatcher доставляет события в том порядке, в каком они поставлены в очередь,
+// даже если их публикуют несколько goroutine одновременно.
+// Очередь разбирает одна goroutine, остальные только добавляют в нее события,
+// поэтому публиковать события можно и из OnNotify.
+type dispatcher struct {
+	mu      sync.Mutex
+	queue   []Event
+	running bool
+}
+
+// push ставит событие в очередь. Чтобы порядок доставки совпадал с порядком Seq,
+// вызывается под той же блокировкой, под которой присваивается Seq.
+func (d *dispatcher) push(e Event) {
+	d.mu.Lock()
+	d.queue = append(d.queue, e)
+	d.mu.Unlock()
+}
+
+// run доставляет очередь через notify, если ее не разбирает другая goroutine.
+func (d *dispatcher) run(notify func(Event)) {
+	d.mu.Lock()
+	if d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = true
+	for len(d.queue) > 0 {
+		events := d.queue
+		d.queue = nil
+		d.mu.Unlock()
+		for _, e := range events {
+			notify(e)
+		}
+		d.mu.Lock()
+	}
+	d.running = false
+	d.mu.Unlock()
+}
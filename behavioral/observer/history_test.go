@@ -0,0 +1,115 @@
+package observer
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// seqRecorder запоминает номера полученных событий.
+type seqRecorder struct {
+	mu   sync.Mutex
+	seqs []uint64
+}
+
+func (r *seqRecorder) OnNotify(e Event) {
+	r.mu.Lock()
+	r.seqs = append(r.seqs, e.Seq)
+	r.mu.Unlock()
+}
+
+// checkGapless проверяет, что получены ровно события 1..n по порядку.
+func (r *seqRecorder) checkGapless(t *testing.T, n int) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.seqs) != n {
+		t.Fatalf("получено %d событий, ожидалось %d", len(r.seqs), n)
+	}
+	for i, seq := range r.seqs {
+		if seq != uint64(i+1) {
+			t.Fatalf("на позиции %d событие %d, ожидалось %d", i, seq, i+1)
+		}
+	}
+}
+
+func TestHistoryConcurrentNotifyNoGaps(t *testing.T) {
+	// Гонку между публикующими goroutine можно увидеть только при параллельном выполнении.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	const goroutines, iterations = 16, 500
+	for run := 0; run < 20; run++ {
+		h := NewHistory(nil, 0, 0)
+		live := &seqRecorder{}
+		h.Register(live)
+		late := &seqRecorder{}
+
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < iterations; i++ {
+					if g == 0 && i == iterations/2 {
+						if err := h.RegisterAfter(late, 0); err != nil {
+							t.Error(err)
+						}
+					}
+					h.Notify(Event{Data: int64(i)})
+				}
+			}(g)
+		}
+		wg.Wait()
+
+		live.checkGapless(t, goroutines*iterations)
+		late.checkGapless(t, goroutines*iterations)
+	}
+}
+
+func TestHistoryNotifyFromObserver(t *testing.T) {
+	h := NewHistory(nil, 0, 0)
+	r := &seqRecorder{}
+	h.Register(ObserverFunc(func(e Event) {
+		if e.Data == 1 {
+			h.Notify(Event{Data: 2})
+		}
+	}))
+	h.Register(r)
+	h.Notify(Event{Data: 1})
+	r.checkGapless(t, 2)
+}
+
+func TestHistoryTruncated(t *testing.T) {
+	start := time.Now()
+	h := NewHistory(nil, 2, 0)
+	for i := 0; i < 5; i++ {
+		h.Notify(Event{Time: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	tests := []struct {
+		name     string
+		register func(Observer) error
+		want     error
+		replayed int
+	}{
+		{"с начала", func(o Observer) error { return h.RegisterAfter(o, 0) }, ErrHistoryTruncated, 2},
+		{"после вытесненного", func(o Observer) error { return h.RegisterAfter(o, 2) }, ErrHistoryTruncated, 2},
+		{"после последнего вытесненного", func(o Observer) error { return h.RegisterAfter(o, 3) }, nil, 2},
+		{"с момента вытесненного", func(o Observer) error { return h.RegisterSince(o, start) }, ErrHistoryTruncated, 2},
+		{"с момента хранимого", func(o Observer) error { return h.RegisterSince(o, start.Add(3*time.Second)) }, nil, 2},
+		{"с будущего момента", func(o Observer) error { return h.RegisterSince(o, start.Add(time.Hour)) }, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &seqRecorder{}
+			if err := tt.register(r); !errors.Is(err, tt.want) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.want)
+			}
+			if len(r.seqs) != tt.replayed {
+				t.Fatalf("воспроизведено %v, ожидалось %d событий", r.seqs, tt.replayed)
+			}
+			h.Deregister(r)
+		})
+	}
+}
@@ -0,0 +1,282 @@
+// Пакет observer предоставляет реализацию паттерна Наблюдатель (Observer).
+// В песочнице play.golang.org: https://play.golang.org/p/lO8OnB73SYs
+package observer
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Event определяет индикацию возникновения момента во времени.
+	Event struct {
+		// Data в этом случае простой int,
+		// но в действительной реализации будет зависеть от приложения.
+		Data int64
+		// Seq - порядковый номер события, начиная с 1.
+		// Заполняется реализациями, хранящими события, например History.
+		Seq uint64
+		// Time - момент публикации события. Заполняется вместе с Seq.
+		Time time.Time
+	}
+
+	// Observer определяет стандартный интерфейс для экземпляров
+	// быть в листе оповещения о возникновении события.
+	Observer interface {
+		// OnNotify позволяет событию быть "опубликованным" для реализаций интерфейса.
+		// В действительных приложениях здесь также рекомендуется реализовать обработку ошибок.
+		OnNotify(Event)
+	}
+
+	// Notifier это интерфейс, за экземпляром, который его реализует, будет происходить наблюдение.
+	Notifier interface {
+		// Register позволяет экземпляру регистрировать себя для прослушивания/наблюдения за событиями.
+		Register(Observer)
+		// Deregister позволяет экземпляру удалять себя из коллекции прослушиваемых/наблюдаемых.
+		Deregister(Observer)
+		// Notify публикует новые события для прослушивателей.
+		// Метод необязателен - каждая реализация может по-своему выполнять оповещения слушателей.
+		Notify(Event)
+	}
+)
+
+// PanicError описывает панику наблюдателя, перехваченную в Notify.
+type PanicError struct {
+	Observer Observer
+	Event    Event
+	// Value - значение, переданное в panic.
+	Value any
+	// Stack - стек goroutine в момент паники.
+	Stack []byte
+	// Deregistered сообщает, что наблюдатель удален, исчерпав MaxPanics.
+	Deregistered bool
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("паника наблюдателя при обработке события %d: %v", e.Event.Data, e.Value)
+}
+
+// EventNotifier - реализация Notifier, безопасная для использования из нескольких goroutine.
+// Нулевое значение готово к использованию.
+//
+// Notify вызывает наблюдателей без удержания блокировки, поэтому наблюдатели
+// могут регистрировать и удалять себя и других прямо из OnNotify.
+// Такие изменения вступают в силу со следующего вызова Notify.
+//
+// Notify вызывает наблюдателей в порядке убывания приоритета (см. RegisterPriority),
+// а при равных приоритетах - в порядке регистрации.
+//
+// Паника в OnNotify одного наблюдателя не прерывает Notify: она перехватывается,
+// передается PanicHandler, и событие доставляется остальным наблюдателям.
+type EventNotifier struct {
+	// PanicHandler получает сведения о каждой перехваченной панике наблюдателя.
+	// Если не задан, паника записывается в стандартный логгер.
+	PanicHandler func(*PanicError)
+	// MaxPanics, если больше нуля, - число паник, после которого
+	// наблюдатель автоматически удаляется.
+	MaxPanics int
+
+	mu sync.Mutex
+	// Использование map позволяет сохранять уникальность слушателей
+	// и находить их регистрацию за O(1).
+	observers map[Observer]*registration
+	// snapshot - список регистраций в порядке доставки, который Notify обходит без блокировки.
+	// Видимая Notify часть списка никогда не меняется: новая регистрация с наименьшим
+	// приоритетом дописывается в конец, а в остальных случаях список копируется.
+	// Удаленная регистрация не вырезается из списка, а помечается за O(1);
+	// список очищается от помеченных регистраций, когда их становится больше половины.
+	snapshot []*registration
+	// removed - число помеченных удаленными регистраций в snapshot.
+	removed int
+	// generation - номер последнего удаления. Notify пропускает регистрации,
+	// удаленные до его начала, а удаленные во время его работы еще получают событие.
+	generation uint64
+	// panics - число паник каждого зарегистрированного наблюдателя.
+	panics map[Observer]int
+	// seq - счетчик регистраций, задающий порядок при равных приоритетах.
+	seq uint64
+}
+
+// registration - зарегистрированный наблюдатель и параметры его регистрации.
+type registration struct {
+	observer Observer
+	filter   Filter
+	priority int
+	seq      uint64
+	// removed - поколение, в котором регистрация удалена, или 0.
+	removed atomic.Uint64
+}
+
+// New возвращает новый EventNotifier без наблюдателей.
+func New() *EventNotifier {
+	return &EventNotifier{}
+}
+
+// Register регистрирует наблюдателя. Повторная регистрация ничего не меняет.
+func (o *EventNotifier) Register(l Observer) {
+	o.update(l, nil)
+}
+
+// RegisterFilter регистрирует наблюдателя, которому доставляются только события,
+// прошедшие фильтр f. Фильтр проверяется в Notify до вызова OnNotify.
+// Для уже зарегистрированного наблюдателя заменяет фильтр, сохраняя приоритет и порядок.
+// Нулевой f снимает фильтр.
+func (o *EventNotifier) RegisterFilter(l Observer, f Filter) {
+	o.update(l, func(r *registration) {
+		r.filter = f
+	})
+}
+
+// RegisterPriority регистрирует наблюдателя с приоритетом priority:
+// наблюдатели с большим приоритетом получают событие раньше.
+// Наблюдатели, зарегистрированные через Register, имеют приоритет 0.
+// Для уже зарегистрированного наблюдателя меняет приоритет, сохраняя его фильтр и позицию
+// среди наблюдателей с тем же приоритетом.
+func (o *EventNotifier) RegisterPriority(l Observer, priority int) {
+	o.update(l, func(r *registration) {
+		r.priority = priority
+	})
+}
+
+func (o *EventNotifier) Deregister(l Observer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	r, ok := o.observers[l]
+	if !ok {
+		return
+	}
+	o.remove(r)
+	delete(o.observers, l)
+	delete(o.panics, l)
+}
+
+func (p *EventNotifier) Notify(e Event) {
+	snapshot, generation := p.registrations()
+	for _, r := range snapshot {
+		if removed := r.removed.Load(); removed != 0 && removed <= generation {
+			continue
+		}
+		p.deliver(r, e)
+	}
+}
+
+// deliver доставляет событие одному наблюдателю, перехватывая панику
+// в его фильтре или OnNotify.
+func (p *EventNotifier) deliver(r *registration, e Event) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.recovered(&PanicError{Observer: r.observer, Event: e, Value: v, Stack: debug.Stack()})
+		}
+	}()
+	if r.filter != nil && !r.filter(e) {
+		return
+	}
+	r.observer.OnNotify(e)
+}
+
+// recovered сообщает о панике и удаляет наблюдателя, исчерпавшего MaxPanics.
+func (p *EventNotifier) recovered(err *PanicError) {
+	p.mu.Lock()
+	if _, ok := p.observers[err.Observer]; ok {
+		if p.panics == nil {
+			p.panics = map[Observer]int{}
+		}
+		p.panics[err.Observer]++
+		if p.MaxPanics > 0 && p.panics[err.Observer] >= p.MaxPanics {
+			p.remove(p.observers[err.Observer])
+			delete(p.observers, err.Observer)
+			delete(p.panics, err.Observer)
+			err.Deregistered = true
+		}
+	}
+	p.mu.Unlock()
+
+	if p.PanicHandler != nil {
+		p.PanicHandler(err)
+		return
+	}
+	log.Printf("%v\n%s", err, err.Stack)
+}
+
+// update регистрирует наблюдателя, если он еще не зарегистрирован,
+// и применяет к его регистрации set. Нулевой set оставляет существующую регистрацию как есть.
+// Регистрация, которая может обходиться в Notify, не меняется на месте,
+// а заменяется измененной копией.
+func (o *EventNotifier) update(l Observer, set func(*registration)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.observers == nil {
+		o.observers = map[Observer]*registration{}
+	}
+	r := &registration{observer: l}
+	if old, ok := o.observers[l]; ok {
+		if set == nil {
+			return
+		}
+		r.filter, r.priority, r.seq = old.filter, old.priority, old.seq
+		o.remove(old)
+	} else {
+		o.seq++
+		r.seq = o.seq
+	}
+	if set != nil {
+		set(r)
+	}
+	o.observers[l] = r
+	o.insert(r)
+}
+
+// insert добавляет регистрацию в snapshot на место, найденное двоичным поиском.
+// Вызывается под o.mu.
+func (o *EventNotifier) insert(r *registration) {
+	i := sort.Search(len(o.snapshot), func(i int) bool { return r.before(o.snapshot[i]) })
+	if i == len(o.snapshot) {
+		// Дописывание не затрагивает элементы, видимые уже выданным Notify спискам.
+		o.snapshot = append(o.snapshot, r)
+		return
+	}
+	snapshot := make([]*registration, 0, len(o.snapshot)+1)
+	snapshot = append(snapshot, o.snapshot[:i]...)
+	snapshot = append(snapshot, r)
+	o.snapshot = append(snapshot, o.snapshot[i:]...)
+}
+
+// remove помечает регистрацию удаленной и при необходимости очищает snapshot.
+// Вызывается под o.mu.
+func (o *EventNotifier) remove(r *registration) {
+	o.generation++
+	r.removed.Store(o.generation)
+	o.removed++
+	if o.removed*2 <= len(o.snapshot) {
+		return
+	}
+	snapshot := make([]*registration, 0, len(o.snapshot)-o.removed)
+	for _, r := range o.snapshot {
+		if r.removed.Load() == 0 {
+			snapshot = append(snapshot, r)
+		}
+	}
+	o.snapshot = snapshot
+	o.removed = 0
+}
+
+// registrations возвращает неизменяемый список регистраций в порядке доставки
+// и поколение удалений, по которому Notify отличает уже удаленные регистрации.
+func (p *EventNotifier) registrations() ([]*registration, uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.snapshot, p.generation
+}
+
+// before сообщает, должна ли регистрация a получать события раньше b.
+func (a *registration) before(b *registration) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
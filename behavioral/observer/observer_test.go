@@ -0,0 +1,182 @@
+package observer
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// counter - наблюдатель, считающий полученные события.
+type counter struct {
+	n atomic.Int64
+}
+
+func (c *counter) OnNotify(Event) { c.n.Add(1) }
+
+// selfRemover удаляет из OnNotify себя и других наблюдателей.
+type selfRemover struct {
+	n      *EventNotifier
+	others []Observer
+	calls  atomic.Int64
+}
+
+func (r *selfRemover) OnNotify(Event) {
+	r.calls.Add(1)
+	r.n.Deregister(r)
+	for _, o := range r.others {
+		r.n.Deregister(o)
+	}
+}
+
+func TestEventNotifierConcurrent(t *testing.T) {
+	n := New()
+	stable := &counter{}
+	n.Register(stable)
+
+	const goroutines, iterations = 8, 500
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				n.Notify(Event{Data: int64(i)})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				c := &counter{}
+				n.Register(c)
+				n.RegisterPriority(c, i%3)
+				n.Deregister(c)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				victim := &counter{}
+				r := &selfRemover{n: n, others: []Observer{victim}}
+				n.Register(victim)
+				n.Register(r)
+				n.Notify(Event{})
+				n.Deregister(r)
+				n.Deregister(victim)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Постоянный наблюдатель получает каждое событие из обоих источников Notify.
+	if got, want := stable.n.Load(), int64(goroutines*iterations*2); got != want {
+		t.Fatalf("постоянный наблюдатель получил %d событий, ожидалось %d", got, want)
+	}
+}
+
+func TestEventNotifierDeregisterDuringNotify(t *testing.T) {
+	n := New()
+	later := &counter{}
+	r := &selfRemover{n: n, others: []Observer{later}}
+	n.Register(r)
+	n.Register(later)
+
+	// Изменения, сделанные из OnNotify, вступают в силу со следующего Notify.
+	n.Notify(Event{})
+	if got := later.n.Load(); got != 1 {
+		t.Fatalf("текущее событие получено %d раз, ожидался 1", got)
+	}
+	n.Notify(Event{})
+	if r.calls.Load() != 1 || later.n.Load() != 1 {
+		t.Fatal("удаленные наблюдатели получили следующее событие")
+	}
+}
+
+// named записывает свое имя в общий журнал.
+type named struct {
+	name string
+	log  *[]string
+}
+
+func (o *named) OnNotify(Event) { *o.log = append(*o.log, o.name) }
+
+func TestEventNotifierOrder(t *testing.T) {
+	var log []string
+	n := New()
+	obs := map[string]*named{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		obs[name] = &named{name: name, log: &log}
+		n.Register(obs[name])
+	}
+	n.RegisterPriority(obs["d"], 10)
+	n.RegisterPriority(obs["b"], 10)
+	n.RegisterPriority(obs["e"], -1)
+	n.Deregister(obs["c"])
+	n.RegisterFilter(obs["a"], func(Event) bool { return true })
+
+	n.Notify(Event{})
+	// При равном приоритете порядок задается исходной регистрацией, а не изменением приоритета.
+	want := "b d a e"
+	if got := fmt.Sprint(log); got != "["+want+"]" {
+		t.Fatalf("порядок доставки %v, ожидался [%s]", got, want)
+	}
+}
+
+// orderRecorder записывает порядок вызова наблюдателей.
+type orderRecorder struct {
+	id  int
+	log *[]int
+}
+
+func (o *orderRecorder) OnNotify(Event) { *o.log = append(*o.log, o.id) }
+
+func TestEventNotifierDeregisterLarge(t *testing.T) {
+	const total = 10000
+	var log []int
+	n := New()
+	obs := make([]*orderRecorder, total)
+	for i := range obs {
+		obs[i] = &orderRecorder{id: i, log: &log}
+		n.RegisterPriority(obs[i], i%7)
+	}
+	// Удаляем две трети наблюдателей вперемешку, чтобы список несколько раз очищался.
+	removed := map[int]bool{}
+	for i := range obs {
+		if i%3 != 0 {
+			id := (i * 7919) % total
+			n.Deregister(obs[id])
+			removed[id] = true
+		}
+	}
+
+	var want []int
+	for priority := 6; priority >= 0; priority-- {
+		for i := range obs {
+			if i%7 == priority && !removed[i] {
+				want = append(want, i)
+			}
+		}
+	}
+
+	n.Notify(Event{})
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Fatalf("получено %d событий в неверном порядке, ожидалось %d", len(log), len(want))
+	}
+	if n.removed*2 > len(n.snapshot) {
+		t.Fatalf("в списке %d удаленных регистраций из %d", n.removed, len(n.snapshot))
+	}
+}
+
+func BenchmarkDeregister(b *testing.B) {
+	n := New()
+	obs := make([]*counter, b.N)
+	for i := range obs {
+		obs[i] = &counter{}
+		n.Register(obs[i])
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, o := range obs {
+		n.Deregister(o)
+	}
+}
@@ -0,0 +1,197 @@
+package observer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrObserver - наблюдатель, сообщающий об ошибке обработки события.
+type ErrObserver interface {
+	OnNotify(Event) error
+}
+
+// RetryPolicy определяет, сколько раз и с какими паузами повторять неудачную доставку.
+type RetryPolicy struct {
+	// Attempts - общее число попыток доставки, включая первую.
+	// Значения меньше единицы означают одну попытку.
+	Attempts int
+	// Backoff возвращает паузу перед повторной попыткой с номером retry, начиная с 1.
+	// Нулевой Backoff означает повтор без паузы.
+	Backoff func(retry int) time.Duration
+}
+
+// ExponentialBackoff возвращает функцию пауз, удваивающую паузу с каждым повтором,
+// начиная с base и не превышая max.
+func ExponentialBackoff(base, max time.Duration) func(int) time.Duration {
+	return func(retry int) time.Duration {
+		d := base
+		for i := 1; i < retry && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			d = max
+		}
+		return d
+	}
+}
+
+// Permanent помечает ошибку как постоянную: доставка с такой ошибкой не повторяется.
+func Permanent(err error) error {
+	return &permanentError{err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// DeliveryError описывает неудачную доставку события одному наблюдателю.
+type DeliveryError struct {
+	Observer ErrObserver
+	Attempts int
+	// Err - ошибка последней попытки.
+	Err error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("доставка не удалась (попыток: %d): %v", e.Attempts, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error { return e.Err }
+
+// NotifyError - сводный отчет о наблюдателях, которым не удалось доставить событие.
+type NotifyError struct {
+	Event    Event
+	Failures []*DeliveryError
+}
+
+func (e *NotifyError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("событие %d не доставлено %d наблюдателям: %s",
+		e.Event.Data, len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// Unwrap позволяет проверять ошибки отдельных доставок через errors.Is и errors.As.
+func (e *NotifyError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
+
+// ReliableNotifier доставляет события наблюдателям ErrObserver, повторяя неудачные
+// доставки согласно политике Retry. Каждая окончательно неудачная доставка
+// передается в DeadLetter.
+//
+// ReliableNotifier не реализует Notifier, потому что его Notify возвращает ошибку.
+// Нулевое значение готово к использованию и делает одну попытку без DeadLetter.
+type ReliableNotifier struct {
+	Retry RetryPolicy
+	// DeadLetter, если задан, вызывается для каждого наблюдателя, которому
+	// событие окончательно не удалось доставить. err указывает наблюдателя и причину,
+	// поэтому доставку каждому из них можно повторить отдельно.
+	// Чтобы передавать такие события наблюдателю, используйте DeadLetterTo.
+	DeadLetter func(e Event, err *DeliveryError)
+
+	mu        sync.Mutex
+	observers map[ErrObserver]struct{}
+	snapshot  []ErrObserver
+}
+
+// DeadLetterTo возвращает обработчик для ReliableNotifier.DeadLetter, который передает
+// наблюдателю o каждое недоставленное событие - по разу на каждого наблюдателя,
+// которому его не удалось доставить. Notifier, например Log или AsyncNotifier,
+// подключается через ObserverFunc(n.Notify).
+func DeadLetterTo(o Observer) func(Event, *DeliveryError) {
+	return func(e Event, _ *DeliveryError) {
+		o.OnNotify(e)
+	}
+}
+
+// NewReliable возвращает ReliableNotifier с политикой retry и обработчиком deadLetter.
+func NewReliable(retry RetryPolicy, deadLetter func(Event, *DeliveryError)) *ReliableNotifier {
+	return &ReliableNotifier{Retry: retry, DeadLetter: deadLetter}
+}
+
+func (n *ReliableNotifier) Register(o ErrObserver) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.observers == nil {
+		n.observers = map[ErrObserver]struct{}{}
+	}
+	if _, ok := n.observers[o]; ok {
+		return
+	}
+	n.observers[o] = struct{}{}
+	n.snapshot = nil
+}
+
+func (n *ReliableNotifier) Deregister(o ErrObserver) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.observers[o]; !ok {
+		return
+	}
+	delete(n.observers, o)
+	n.snapshot = nil
+}
+
+// Notify доставляет событие всем наблюдателям по очереди, повторяя неудачные доставки.
+// Возвращает *NotifyError, если хотя бы одному наблюдателю доставить событие не удалось.
+func (n *ReliableNotifier) Notify(e Event) error {
+	var failures []*DeliveryError
+	for _, o := range n.observerList() {
+		if err := n.deliver(o, e); err != nil {
+			failures = append(failures, err)
+			if n.DeadLetter != nil {
+				n.DeadLetter(e, err)
+			}
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &NotifyError{Event: e, Failures: failures}
+}
+
+func (n *ReliableNotifier) deliver(o ErrObserver, e Event) *DeliveryError {
+	attempts := n.Retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 && n.Retry.Backoff != nil {
+			time.Sleep(n.Retry.Backoff(attempt - 1))
+		}
+		if err = o.OnNotify(e); err == nil {
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return &DeliveryError{Observer: o, Attempts: attempt, Err: permanent.err}
+		}
+	}
+	return &DeliveryError{Observer: o, Attempts: attempts, Err: err}
+}
+
+func (n *ReliableNotifier) observerList() []ErrObserver {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.snapshot == nil && len(n.observers) > 0 {
+		n.snapshot = make([]ErrObserver, 0, len(n.observers))
+		for o := range n.observers {
+			n.snapshot = append(n.snapshot, o)
+		}
+	}
+	return n.snapshot
+}
@@ -0,0 +1,76 @@
+package observer
+
+import (
+	"errors"
+	"testing"
+)
+
+// failing - наблюдатель, возвращающий err на первые fails вызовов.
+type failing struct {
+	fails int
+	err   error
+	calls int
+}
+
+func (f *failing) OnNotify(Event) error {
+	f.calls++
+	if f.calls <= f.fails {
+		return f.err
+	}
+	return nil
+}
+
+func TestReliableDeadLetterPerObserver(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	a := &failing{fails: 10, err: errA}
+	b := &failing{fails: 10, err: Permanent(errB)}
+	ok := &failing{fails: 1, err: errors.New("временная")}
+
+	dead := map[ErrObserver]*DeliveryError{}
+	n := NewReliable(RetryPolicy{Attempts: 3}, func(e Event, err *DeliveryError) {
+		if e.Data != 7 {
+			t.Errorf("в DeadLetter событие %d, ожидалось 7", e.Data)
+		}
+		dead[err.Observer] = err
+	})
+	n.Register(a)
+	n.Register(b)
+	n.Register(ok)
+
+	err := n.Notify(Event{Data: 7})
+	var notifyErr *NotifyError
+	if !errors.As(err, &notifyErr) || len(notifyErr.Failures) != 2 {
+		t.Fatalf("Notify = %v, ожидались две неудачные доставки", err)
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("ошибки наблюдателей не видны через errors.Is: %v", err)
+	}
+
+	if len(dead) != 2 {
+		t.Fatalf("DeadLetter вызван для %d наблюдателей, ожидалось 2", len(dead))
+	}
+	if d := dead[a]; d == nil || d.Attempts != 3 || !errors.Is(d.Err, errA) {
+		t.Errorf("DeadLetter для a: %+v", d)
+	}
+	if d := dead[b]; d == nil || d.Attempts != 1 || !errors.Is(d.Err, errB) {
+		t.Errorf("постоянная ошибка повторялась или потеряна: %+v", d)
+	}
+	if ok.calls != 2 {
+		t.Errorf("временная ошибка: %d попыток, ожидалось 2", ok.calls)
+	}
+}
+
+func TestReliableDeadLetterTo(t *testing.T) {
+	// Недоставленные события сохраняются в истории, откуда их можно воспроизвести позже.
+	dead := NewHistory(nil, 0, 0)
+	n := NewReliable(RetryPolicy{}, DeadLetterTo(ObserverFunc(dead.Notify)))
+	n.Register(&failing{fails: 10, err: errors.New("a")})
+	n.Register(&failing{fails: 10, err: errors.New("b")})
+	n.Register(&failing{})
+
+	n.Notify(Event{Data: 7})
+	events := dead.Events()
+	if len(events) != 2 || events[0].Data != 7 || events[1].Data != 7 {
+		t.Fatalf("в очередь недоставленных попало %+v, ожидалось событие 7 дважды", events)
+	}
+}
@@ -0,0 +1,198 @@
+// Пакет sse транслирует события наблюдателя клиентам по протоколу Server-Sent Events,
+// так что за ними можно следить из браузера или через curl.
+package sse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/A1esandr/golang-design-patterns/behavioral/observer"
+)
+
+const (
+	// DefaultHeartbeat - интервал комментариев-пульса, не дающих прокси закрыть простаивающее соединение.
+	DefaultHeartbeat = 15 * time.Second
+	// DefaultBuffer - размер очереди событий одного клиента.
+	DefaultBuffer = 256
+)
+
+// Source - источник событий с воспроизведением истории,
+// например *observer.History или *observer.Log.
+type Source interface {
+	observer.Notifier
+	RegisterAfter(o observer.Observer, seq uint64) error
+}
+
+// Handler - http.Handler, регистрирующий наблюдателя для каждого подключенного клиента
+// и передающий ему события как SSE. Номер события (Seq) передается в поле id,
+// поэтому клиент, переподключившийся с заголовком Last-Event-ID,
+// получает пропущенные события из истории Source.
+//
+// Если клиент не успевает читать новые события и его очередь переполняется,
+// соединение закрывается: клиент переподключится и продолжит с последнего полученного события.
+// События из истории при переподключении, напротив, передаются со скоростью клиента,
+// поэтому воспроизведение длиннее очереди не обрывает соединение.
+type Handler struct {
+	Source Source
+	// Heartbeat - интервал пульса. Нулевое значение означает DefaultHeartbeat.
+	Heartbeat time.Duration
+	// Buffer - размер очереди событий клиента. Нулевое значение означает DefaultBuffer.
+	Buffer int
+}
+
+// NewHandler возвращает Handler для src с настройками по умолчанию.
+func NewHandler(src Source) *Handler {
+	return &Handler{Source: src}
+}
+
+// message - представление события в поле data.
+type message struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Data int64     `json:"data"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	var (
+		lastID uint64
+		resume bool
+		err    error
+		header = w.Header()
+	)
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		if lastID, err = strconv.ParseUint(id, 10, 64); err != nil {
+			http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		resume = true
+	}
+
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c := newClient(h.buffer())
+	// Регистрируем клиента в отдельной goroutine: воспроизведение истории
+	// может превысить размер очереди, поэтому ее нужно читать уже во время регистрации.
+	registered := make(chan error, 1)
+	go func() {
+		if resume {
+			c.replaying.Store(true)
+			err := h.Source.RegisterAfter(c, lastID)
+			c.replaying.Store(false)
+			registered <- err
+			return
+		}
+		h.Source.Register(c)
+		registered <- nil
+	}()
+	defer func() {
+		// Освобождаем воспроизведение, ожидающее места в очереди.
+		close(c.done)
+		if registered != nil {
+			<-registered
+		}
+		h.Source.Deregister(c)
+	}()
+
+	heartbeat := time.NewTicker(h.heartbeat())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case err := <-registered:
+			registered = nil
+			if err != nil && !errors.Is(err, observer.ErrHistoryTruncated) {
+				return
+			}
+		case e := <-c.events:
+			if err := writeEvent(w, e); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-c.overflow:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) heartbeat() time.Duration {
+	if h.Heartbeat > 0 {
+		return h.Heartbeat
+	}
+	return DefaultHeartbeat
+}
+
+func (h *Handler) buffer() int {
+	if h.Buffer > 0 {
+		return h.Buffer
+	}
+	return DefaultBuffer
+}
+
+func writeEvent(w http.ResponseWriter, e observer.Event) error {
+	data, err := json.Marshal(message{Seq: e.Seq, Time: e.Time, Data: e.Data})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.Seq, data)
+	return err
+}
+
+// client - наблюдатель одного подключения. Новые события OnNotify не ждет,
+// чтобы медленный клиент не задерживал Notify остальных наблюдателей.
+// Во время воспроизведения истории OnNotify ждет места в очереди: события из истории
+// доставляет goroutine регистрации клиента, а не Notify источника. Notify, пришедший
+// в самом конце воспроизведения, тоже может подождать, но очередь в это время
+// разбирает обработчик подключения.
+type client struct {
+	events   chan observer.Event
+	overflow chan struct{}
+	once     sync.Once
+	// replaying - идет воспроизведение истории.
+	replaying atomic.Bool
+	// done закрывается, когда обработчик подключения завершился.
+	done chan struct{}
+}
+
+func newClient(buffer int) *client {
+	return &client{
+		events:   make(chan observer.Event, buffer),
+		overflow: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *client) OnNotify(e observer.Event) {
+	if c.replaying.Load() {
+		select {
+		case c.events <- e:
+		case <-c.done:
+		}
+		return
+	}
+	select {
+	case c.events <- e:
+	default:
+		c.once.Do(func() { close(c.overflow) })
+	}
+}
@@ -0,0 +1,267 @@
+package sse
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/A1esandr/golang-design-patterns/behavioral/observer"
+)
+
+// stream - чтение событий SSE из ответа сервера.
+type stream struct {
+	t    *testing.T
+	resp *http.Response
+	sc   *bufio.Scanner
+}
+
+func connect(t *testing.T, url, lastID string) *stream {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("статус %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type %q", ct)
+	}
+	return &stream{t: t, resp: resp, sc: bufio.NewScanner(resp.Body)}
+}
+
+// next возвращает следующее событие, пропуская комментарии.
+func (s *stream) next() observer.Event {
+	s.t.Helper()
+	e, err := s.read()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return e
+}
+
+// read читает следующее событие. В отличие от next, его можно вызывать
+// не из goroutine теста.
+func (s *stream) read() (observer.Event, error) {
+	var (
+		id  uint64
+		msg message
+	)
+	for s.sc.Scan() {
+		line := s.sc.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id, _ = strconv.ParseUint(strings.TrimPrefix(line, "id: "), 10, 64)
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg); err != nil {
+				return observer.Event{}, err
+			}
+		case line == "" && id != 0:
+			if msg.Seq != id {
+				return observer.Event{}, fmt.Errorf("id %d не совпадает с seq %d", id, msg.Seq)
+			}
+			return observer.Event{Seq: msg.Seq, Time: msg.Time, Data: msg.Data}, nil
+		}
+	}
+	return observer.Event{}, fmt.Errorf("поток закончился: %v", s.sc.Err())
+}
+
+// publishUntilReceived публикует события, пока клиент не получит одно из них.
+func publishUntilReceived(t *testing.T, n observer.Notifier, s *stream) observer.Event {
+	t.Helper()
+	type result struct {
+		e   observer.Event
+		err error
+	}
+	received := make(chan result, 1)
+	go func() {
+		e, err := s.read()
+		received <- result{e, err}
+	}()
+	for {
+		n.Notify(observer.Event{Data: 42})
+		select {
+		case r := <-received:
+			if r.err != nil {
+				t.Fatal(r.err)
+			}
+			return r.e
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestHandlerStreamsEvents(t *testing.T) {
+	h := observer.NewHistory(nil, 0, 0)
+	srv := httptest.NewServer(NewHandler(h))
+	t.Cleanup(srv.Close)
+
+	s := connect(t, srv.URL, "")
+	// Клиент регистрируется асинхронно, поэтому публикуем, пока он не получит событие.
+	first := publishUntilReceived(t, h, s)
+	if first.Data != 42 {
+		t.Fatalf("получено %+v", first)
+	}
+	h.Notify(observer.Event{Data: 7})
+	if e := s.next(); e.Seq != h.Events()[len(h.Events())-1].Seq || e.Data != 7 {
+		t.Fatalf("получено %+v, ожидалось последнее событие с Data 7", e)
+	}
+}
+
+func TestHandlerResumesLongHistory(t *testing.T) {
+	const events = 2000
+	h := observer.NewHistory(nil, 0, 0)
+	for i := 1; i <= events; i++ {
+		h.Notify(observer.Event{Data: int64(i)})
+	}
+	// Очередь намного меньше воспроизводимой истории.
+	srv := httptest.NewServer(&Handler{Source: h, Buffer: 16})
+	t.Cleanup(srv.Close)
+
+	s := connect(t, srv.URL, "100")
+	for want := uint64(101); want <= events; want++ {
+		if e := s.next(); e.Seq != want {
+			t.Fatalf("получено событие %d, ожидалось %d", e.Seq, want)
+		}
+	}
+	h.Notify(observer.Event{})
+	if e := s.next(); e.Seq != events+1 {
+		t.Fatalf("после истории получено событие %d, ожидалось %d", e.Seq, events+1)
+	}
+}
+
+func TestHandlerHeartbeat(t *testing.T) {
+	h := observer.NewHistory(nil, 0, 0)
+	srv := httptest.NewServer(&Handler{Source: h, Heartbeat: 10 * time.Millisecond})
+	t.Cleanup(srv.Close)
+
+	s := connect(t, srv.URL, "")
+	if !s.sc.Scan() || s.sc.Text() != ": heartbeat" {
+		t.Fatalf("ожидался пульс, получено %q", s.sc.Text())
+	}
+}
+
+func TestHandlerBadLastEventID(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(observer.NewHistory(nil, 0, 0)))
+	t.Cleanup(srv.Close)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("статус %s, ожидался 400", resp.Status)
+	}
+}
+
+// countingSource считает регистрации и удаления наблюдателей источника.
+type countingSource struct {
+	Source
+
+	mu           sync.Mutex
+	registered   int
+	deregistered int
+}
+
+func (s *countingSource) Register(o observer.Observer) {
+	s.mu.Lock()
+	s.registered++
+	s.mu.Unlock()
+	s.Source.Register(o)
+}
+
+func (s *countingSource) RegisterAfter(o observer.Observer, seq uint64) error {
+	s.mu.Lock()
+	s.registered++
+	s.mu.Unlock()
+	return s.Source.RegisterAfter(o, seq)
+}
+
+func (s *countingSource) Deregister(o observer.Observer) {
+	s.Source.Deregister(o)
+	s.mu.Lock()
+	s.deregistered++
+	s.mu.Unlock()
+}
+
+// waitDeregistered ждет, пока каждый зарегистрированный клиент будет удален.
+func (s *countingSource) waitDeregistered(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		registered, deregistered := s.registered, s.deregistered
+		s.mu.Unlock()
+		if registered > 0 && registered == deregistered {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("зарегистрировано %d клиентов, удалено %d", registered, deregistered)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHandlerDeregistersOnDisconnect(t *testing.T) {
+	tests := []struct {
+		name   string
+		lastID string
+	}{
+		{"новые события", ""},
+		{"продолжение с Last-Event-ID", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := observer.NewHistory(nil, 0, 0)
+			h.Notify(observer.Event{})
+			src := &countingSource{Source: h}
+			srv := httptest.NewServer(NewHandler(src))
+			t.Cleanup(srv.Close)
+
+			s := connect(t, srv.URL, tt.lastID)
+			publishUntilReceived(t, h, s)
+			s.resp.Body.Close()
+			src.waitDeregistered(t)
+		})
+	}
+}
+
+func TestHandlerDisconnectsOnOverflow(t *testing.T) {
+	h := observer.NewHistory(nil, 0, 0)
+	src := &countingSource{Source: h}
+	srv := httptest.NewServer(&Handler{Source: src, Buffer: 1})
+	t.Cleanup(srv.Close)
+
+	s := connect(t, srv.URL, "")
+	publishUntilReceived(t, h, s)
+	// Публикуем быстрее, чем обработчик успевает передавать события клиенту.
+	for i := 0; i < 100000; i++ {
+		h.Notify(observer.Event{})
+	}
+	src.waitDeregistered(t)
+
+	// Сервер закрыл поток, не дожидаясь клиента.
+	for {
+		if _, err := s.read(); err != nil {
+			break
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package observer
+
+import (
+	"context"
+	"sync"
+)
+
+// SubscriptionBuffer - размер буфера канала подписки, созданной через Subscribe.
+const SubscriptionBuffer = 16
+
+// ObserverFunc превращает функцию в Observer.
+// В отличие от адаптеров-типов вроде http.HandlerFunc, возвращает указатель,
+// поэтому результат можно использовать как ключ и передать в Deregister.
+func ObserverFunc(f func(Event)) Observer {
+	return &funcObserver{f: f}
+}
+
+type funcObserver struct {
+	f func(Event)
+}
+
+func (o *funcObserver) OnNotify(e Event) {
+	o.f(e)
+}
+
+// Unsubscribe завершает подписку и закрывает ее канал. Повторные вызовы ничего не делают.
+type Unsubscribe func()
+
+// Subscribe регистрирует подписку, доставляющую события в возвращаемый канал,
+// который удобно читать в select вместе с другими каналами.
+// Подписка завершается вызовом Unsubscribe или отменой ctx, после чего канал закрывается.
+//
+// Канал буферизован на SubscriptionBuffer событий. Если буфер заполнен,
+// Notify ждет, пока подписчик прочитает событие или завершит подписку,
+// поэтому подписчик должен либо читать канал, либо отписаться.
+func (p *EventNotifier) Subscribe(ctx context.Context) (<-chan Event, Unsubscribe) {
+	s := &subscription{
+		ch:   make(chan Event, SubscriptionBuffer),
+		done: make(chan struct{}),
+	}
+	p.Register(s)
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			p.Deregister(s)
+			s.close()
+		})
+	}
+	// AfterFunc не запускает goroutine, пока ctx не отменен,
+	// поэтому подписки на неотменяемый контекст ничего не стоят.
+	stop := context.AfterFunc(ctx, unsubscribe)
+	return s.ch, func() {
+		stop()
+		unsubscribe()
+	}
+}
+
+// subscription - наблюдатель, пересылающий события в канал.
+type subscription struct {
+	ch chan Event
+	// done закрывается при завершении подписки и прерывает ожидающие отправки.
+	done chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func (s *subscription) OnNotify(e Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- e:
+	case <-s.done:
+	}
+}
+
+func (s *subscription) close() {
+	// Сначала прерываем ожидающие отправки, иначе они не отпустят s.mu.
+	close(s.done)
+	s.mu.Lock()
+	s.closed = true
+	close(s.ch)
+	s.mu.Unlock()
+}
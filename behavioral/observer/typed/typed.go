@@ -0,0 +1,186 @@
+// Пакет typed предоставляет обобщенный вариант паттерна Наблюдатель:
+// события несут данные произвольного типа T и публикуются в именованные темы (topics).
+//
+// Темы состоят из уровней, разделенных точкой, например orders.created.eu.
+// При подписке вместо уровня можно указать шаблон в стиле MQTT/AMQP:
+// * соответствует ровно одному уровню, # - любому числу уровней, в том числе нулю.
+// Так, orders.* получает orders.created, но не orders.created.eu,
+// а orders.# получает orders, orders.created и orders.created.eu.
+package typed
+
+import (
+	"strings"
+	"sync"
+)
+
+type (
+	// Event - событие с данными типа T, опубликованное в тему Topic.
+	Event[T any] struct {
+		Topic string
+		Data  T
+	}
+
+	// Observer получает события с данными типа T.
+	Observer[T any] interface {
+		OnNotify(Event[T])
+	}
+
+	// Notifier публикует события наблюдателям, подписанным на тему события.
+	Notifier[T any] interface {
+		// Register подписывает наблюдателя на тему или шаблон тем pattern.
+		// Один наблюдатель может быть подписан на несколько шаблонов,
+		// но каждое событие получает не больше одного раза.
+		Register(pattern string, o Observer[T])
+		// Deregister отписывает наблюдателя от шаблона pattern.
+		Deregister(pattern string, o Observer[T])
+		// Notify публикует событие подписчикам темы e.Topic.
+		Notify(e Event[T])
+	}
+)
+
+const (
+	// Separator разделяет уровни темы.
+	Separator = "."
+	// SingleLevel в шаблоне соответствует ровно одному уровню темы.
+	SingleLevel = "*"
+	// MultiLevel в шаблоне соответствует любому числу уровней темы.
+	MultiLevel = "#"
+)
+
+// maxCached ограничивает число тем, для которых запоминаются списки подписчиков.
+const maxCached = 1024
+
+// EventNotifier - реализация Notifier, безопасная для использования из нескольких goroutine.
+// Подписки хранятся в префиксном дереве по уровням шаблонов, поэтому поиск подписчиков
+// зависит от глубины темы, а не от общего числа подписок.
+// Как и observer.EventNotifier, вызывает наблюдателей без удержания блокировки,
+// поэтому наблюдатели могут менять подписки прямо из OnNotify.
+// Нулевое значение готово к использованию.
+type EventNotifier[T any] struct {
+	mu   sync.Mutex
+	root node[T]
+	// cache - неизменяемые списки подписчиков уже встречавшихся тем.
+	// Очищается при любом изменении подписок.
+	cache map[string][]Observer[T]
+}
+
+// node - узел дерева подписок, соответствующий одному уровню шаблона.
+type node[T any] struct {
+	children  map[string]*node[T]
+	observers map[Observer[T]]struct{}
+}
+
+// New возвращает новый EventNotifier без подписчиков.
+func New[T any]() *EventNotifier[T] {
+	return &EventNotifier[T]{}
+}
+
+func (n *EventNotifier[T]) Register(pattern string, o Observer[T]) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	nd := &n.root
+	for _, level := range strings.Split(pattern, Separator) {
+		child, ok := nd.children[level]
+		if !ok {
+			if nd.children == nil {
+				nd.children = map[string]*node[T]{}
+			}
+			child = &node[T]{}
+			nd.children[level] = child
+		}
+		nd = child
+	}
+	if nd.observers == nil {
+		nd.observers = map[Observer[T]]struct{}{}
+	}
+	if _, ok := nd.observers[o]; ok {
+		return
+	}
+	nd.observers[o] = struct{}{}
+	n.cache = nil
+}
+
+func (n *EventNotifier[T]) Deregister(pattern string, o Observer[T]) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	levels := strings.Split(pattern, Separator)
+	path := make([]*node[T], 0, len(levels)+1)
+	nd := &n.root
+	path = append(path, nd)
+	for _, level := range levels {
+		child, ok := nd.children[level]
+		if !ok {
+			return
+		}
+		nd = child
+		path = append(path, nd)
+	}
+	if _, ok := nd.observers[o]; !ok {
+		return
+	}
+	delete(nd.observers, o)
+	n.cache = nil
+
+	// Удаляем опустевшие узлы снизу вверх.
+	for i := len(levels); i > 0; i-- {
+		if len(path[i].observers) > 0 || len(path[i].children) > 0 {
+			break
+		}
+		delete(path[i-1].children, levels[i-1])
+	}
+}
+
+func (n *EventNotifier[T]) Notify(e Event[T]) {
+	for _, o := range n.observerList(e.Topic) {
+		o.OnNotify(e)
+	}
+}
+
+func (n *EventNotifier[T]) observerList(topic string) []Observer[T] {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if list, ok := n.cache[topic]; ok {
+		return list
+	}
+
+	var matched []*node[T]
+	n.root.match(strings.Split(topic, Separator), &matched)
+	var list []Observer[T]
+	seen := map[Observer[T]]struct{}{}
+	for _, nd := range matched {
+		for o := range nd.observers {
+			if _, ok := seen[o]; !ok {
+				seen[o] = struct{}{}
+				list = append(list, o)
+			}
+		}
+	}
+
+	if n.cache == nil || len(n.cache) >= maxCached {
+		n.cache = map[string][]Observer[T]{}
+	}
+	n.cache[topic] = list
+	return list
+}
+
+// match добавляет в matched узлы, шаблоны которых соответствуют уровням levels.
+func (nd *node[T]) match(levels []string, matched *[]*node[T]) {
+	if multi, ok := nd.children[MultiLevel]; ok {
+		// # поглощает от нуля до всех оставшихся уровней.
+		for i := 0; i <= len(levels); i++ {
+			multi.match(levels[i:], matched)
+		}
+	}
+	if len(levels) == 0 {
+		if len(nd.observers) > 0 {
+			*matched = append(*matched, nd)
+		}
+		return
+	}
+	if child, ok := nd.children[levels[0]]; ok {
+		child.match(levels[1:], matched)
+	}
+	if single, ok := nd.children[SingleLevel]; ok {
+		single.match(levels[1:], matched)
+	}
+}
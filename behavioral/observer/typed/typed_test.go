@@ -0,0 +1,154 @@
+package typed
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+// recorder считает полученные события.
+type recorder struct {
+	n atomic.Int64
+}
+
+func (r *recorder) OnNotify(Event[int]) { r.n.Add(1) }
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"orders", "orders", true},
+		{"orders", "orders.created", false},
+		{"orders.created", "orders.created", true},
+		{"orders.created", "orders.deleted", false},
+
+		{"orders.*", "orders.created", true},
+		{"orders.*", "orders", false},
+		{"orders.*", "orders.created.eu", false},
+		{"*.created", "orders.created", true},
+		{"*.created", "created", false},
+		{"*", "orders", true},
+		{"*", "orders.created", false},
+
+		{"orders.#", "orders", true},
+		{"orders.#", "orders.created", true},
+		{"orders.#", "orders.created.eu", true},
+		{"orders.#", "payments.created", false},
+		{"#", "orders.created.eu", true},
+		{"#.eu", "eu", true},
+		{"#.eu", "orders.created.eu", true},
+		{"#.eu", "orders.created.us", false},
+		{"orders.#.eu", "orders.eu", true},
+		{"orders.#.eu", "orders.created.paid.eu", true},
+		{"orders.*.#", "orders", false},
+		{"orders.*.#", "orders.created", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.topic, func(t *testing.T) {
+			n := New[int]()
+			r := &recorder{}
+			n.Register(tt.pattern, r)
+			n.Notify(Event[int]{Topic: tt.topic})
+			if got := r.n.Load() == 1; got != tt.want {
+				t.Errorf("шаблон %q и тема %q: получено %v, ожидалось %v", tt.pattern, tt.topic, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlappingPatternsDeliverOnce(t *testing.T) {
+	n := New[int]()
+	r := &recorder{}
+	for _, p := range []string{"orders.created", "orders.*", "orders.#", "#", "*.created"} {
+		n.Register(p, r)
+	}
+	n.Notify(Event[int]{Topic: "orders.created"})
+	if got := r.n.Load(); got != 1 {
+		t.Fatalf("наблюдатель с пересекающимися шаблонами получил событие %d раз, ожидался 1", got)
+	}
+
+	// После отписки от части шаблонов событие по-прежнему приходит через оставшиеся.
+	n.Deregister("orders.created", r)
+	n.Deregister("#", r)
+	n.Notify(Event[int]{Topic: "orders.created"})
+	if got := r.n.Load(); got != 2 {
+		t.Fatalf("после отписки получено %d событий, ожидалось 2", got)
+	}
+}
+
+func TestDeregisterPrunesTree(t *testing.T) {
+	n := New[int]()
+	r := &recorder{}
+	n.Register("a.b.c", r)
+	n.Deregister("a.b.c", r)
+	if len(n.root.children) != 0 {
+		t.Fatalf("после отписки в дереве остались узлы: %v", n.root.children)
+	}
+}
+
+// benchSubscriptions - число подписок в каждом бенчмарке.
+const benchSubscriptions = 5000
+
+func benchmarkNotify(b *testing.B, pattern func(i int) string, topic func(i int) string) {
+	n := New[int]()
+	for i := 0; i < benchSubscriptions; i++ {
+		n.Register(pattern(i), &recorder{})
+	}
+	b.Run("cached", func(b *testing.B) {
+		e := Event[int]{Topic: topic(0)}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			n.Notify(e)
+		}
+	})
+	// Тем больше, чем вмещает кэш, поэтому подписчики ищутся в дереве.
+	b.Run("uncached", func(b *testing.B) {
+		topics := make([]string, 4*maxCached)
+		for i := range topics {
+			topics[i] = topic(i)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			n.Notify(Event[int]{Topic: topics[i%len(topics)]})
+		}
+	})
+}
+
+func BenchmarkNotifyExact(b *testing.B) {
+	benchmarkNotify(b,
+		func(i int) string { return fmt.Sprintf("orders.%d.created", i) },
+		func(i int) string { return fmt.Sprintf("orders.%d.created", i%benchSubscriptions) },
+	)
+}
+
+func BenchmarkNotifySingleLevel(b *testing.B) {
+	benchmarkNotify(b,
+		func(i int) string { return fmt.Sprintf("orders.*.%d", i) },
+		func(i int) string { return fmt.Sprintf("orders.%d.%d", i, i%benchSubscriptions) },
+	)
+}
+
+func BenchmarkNotifyMultiLevel(b *testing.B) {
+	benchmarkNotify(b,
+		func(i int) string { return fmt.Sprintf("region%d.#", i) },
+		func(i int) string { return fmt.Sprintf("region%d.orders.created.%d", i%benchSubscriptions, i) },
+	)
+}
+
+// BenchmarkNotifyFanOut - все подписки соответствуют каждой теме.
+func BenchmarkNotifyFanOut(b *testing.B) {
+	patterns := []string{"orders.created", "orders.*", "orders.#"}
+	n := New[int]()
+	for i := 0; i < benchSubscriptions; i++ {
+		n.Register(patterns[i%len(patterns)], &recorder{})
+	}
+	e := Event[int]{Topic: "orders.created"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n.Notify(e)
+	}
+}
@@ -0,0 +1,371 @@
+// Пакет webhook предоставляет наблюдателя, отправляющего события во внешние системы
+// HTTP-запросами POST с подписанным JSON.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/A1esandr/golang-design-patterns/behavioral/observer"
+)
+
+const (
+	// SignatureHeader содержит подпись запроса в виде sha256=<hex HMAC-SHA256>.
+	// Подписываются TimestampHeader и тело запроса (см. Sign).
+	SignatureHeader = "X-Signature-256"
+	// TimestampHeader содержит момент отправки запроса в секундах Unix.
+	// Получатель отклоняет запросы со слишком старой меткой, защищаясь от их повтора (см. Verify).
+	TimestampHeader = "X-Webhook-Timestamp"
+	// EventIDHeader содержит номер события (Seq).
+	EventIDHeader = "X-Event-Id"
+
+	// DefaultTimeout - таймаут одного запроса, если Client не задан.
+	DefaultTimeout = 10 * time.Second
+	// DefaultFailureThreshold - число неудачных доставок подряд, после которого
+	// конечная точка временно отключается.
+	DefaultFailureThreshold = 5
+	// DefaultCooldown - время, на которое отключается конечная точка.
+	DefaultCooldown = 30 * time.Second
+)
+
+var (
+	// ErrCircuitOpen возвращается для конечной точки, временно отключенной после серии неудач.
+	ErrCircuitOpen = errors.New("конечная точка временно отключена после серии неудачных доставок")
+	// ErrBadSignature возвращается Verify, если подпись отсутствует или не совпадает.
+	ErrBadSignature = errors.New("неверная подпись запроса")
+	// ErrStaleTimestamp возвращается Verify, если метка времени запроса вне допустимого окна.
+	ErrStaleTimestamp = errors.New("метка времени запроса устарела")
+)
+
+// State - состояние автоматического выключателя (circuit breaker) конечной точки.
+type State int
+
+const (
+	// Closed - конечная точка доступна.
+	Closed State = iota
+	// Open - конечная точка отключена до истечения Cooldown.
+	Open
+	// HalfOpen - Cooldown истек, выполняется пробная доставка.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Delivery - результат доставки события одной конечной точке.
+type Delivery struct {
+	URL   string
+	Event observer.Event
+	// Attempts - число выполненных запросов; 0, если выключатель не пропустил доставку.
+	Attempts int
+	// StatusCode - код ответа последнего запроса или 0, если ответа не было.
+	StatusCode int
+	// Err равна nil при успешной доставке.
+	Err error
+}
+
+// Status - сводка доставок одной конечной точке.
+type Status struct {
+	URL       string
+	State     State
+	Delivered uint64
+	Failed    uint64
+	// ConsecutiveFailures - число неудачных доставок подряд.
+	ConsecutiveFailures int
+	LastDelivery        time.Time
+	LastError           error
+}
+
+// Observer - наблюдатель, отправляющий каждое событие JSON-запросом POST на все URL.
+// Тело вместе с меткой времени подписывается HMAC-SHA256 с ключом Secret, неудачные запросы повторяются
+// согласно Retry, а у каждой конечной точки свой выключатель: после FailureThreshold
+// неудачных доставок подряд она отключается на Cooldown.
+//
+// OnNotify выполняет доставку синхронно, включая паузы между повторами.
+// Чтобы не задерживать других наблюдателей, регистрируйте Observer в observer.AsyncNotifier.
+type Observer struct {
+	Client *http.Client
+	Secret []byte
+	Retry  observer.RetryPolicy
+	// FailureThreshold и Cooldown настраивают выключатель.
+	// Нулевые значения означают DefaultFailureThreshold и DefaultCooldown.
+	FailureThreshold int
+	Cooldown         time.Duration
+	// OnDelivery, если задан, получает результат каждой доставки.
+	OnDelivery func(Delivery)
+
+	endpoints []*endpoint
+}
+
+// New возвращает Observer для urls с подписью secret,
+// тремя попытками доставки и экспоненциальной паузой между ними.
+func New(secret []byte, urls ...string) *Observer {
+	o := &Observer{
+		Secret: secret,
+		Retry: observer.RetryPolicy{
+			Attempts: 3,
+			Backoff:  observer.ExponentialBackoff(100*time.Millisecond, 5*time.Second),
+		},
+	}
+	for _, url := range urls {
+		o.endpoints = append(o.endpoints, &endpoint{url: url})
+	}
+	return o
+}
+
+// OnNotify доставляет событие на все конечные точки.
+// Результаты доставки передаются в OnDelivery и учитываются в Status.
+func (o *Observer) OnNotify(e observer.Event) {
+	o.Deliver(e)
+}
+
+// Deliver доставляет событие на все конечные точки и возвращает
+// объединенную ошибку неудачных доставок.
+func (o *Observer) Deliver(e observer.Event) error {
+	body, err := json.Marshal(payload{Seq: e.Seq, Time: e.Time, Data: e.Data})
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, ep := range o.endpoints {
+		d := o.deliver(ep, e, body)
+		if o.OnDelivery != nil {
+			o.OnDelivery(d)
+		}
+		if d.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ep.url, d.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithErrors возвращает o в виде observer.ErrObserver, чей OnNotify
+// возвращает ошибку Deliver, например для регистрации в observer.ReliableNotifier.
+// Повтор в ReliableNotifier заново отправляет событие на все конечные точки,
+// включая те, которые его уже получили.
+func (o *Observer) WithErrors() observer.ErrObserver {
+	return errObserver{o}
+}
+
+type errObserver struct {
+	o *Observer
+}
+
+func (e errObserver) OnNotify(ev observer.Event) error {
+	return e.o.Deliver(ev)
+}
+
+// Status возвращает сводку доставок по каждой конечной точке.
+func (o *Observer) Status() []Status {
+	statuses := make([]Status, len(o.endpoints))
+	for i, ep := range o.endpoints {
+		ep.mu.Lock()
+		statuses[i] = ep.status
+		statuses[i].URL = ep.url
+		statuses[i].State = ep.state(time.Now(), o.cooldown())
+		ep.mu.Unlock()
+	}
+	return statuses
+}
+
+// payload - тело запроса.
+type payload struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Data int64     `json:"data"`
+}
+
+// Sign возвращает значение SignatureHeader для значения TimestampHeader timestamp и тела body:
+// HMAC-SHA256 строки "<timestamp>.<body>" с ключом secret.
+// Получатель вычисляет подпись тем же ключом и сравнивает ее через hmac.Equal, как это делает Verify.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса с заголовками header и телом body
+// и то, что его метка времени отличается от текущего времени не больше чем на tolerance.
+// Повторно отправленный перехваченный запрос отклоняется, как только его метка устареет.
+func Verify(secret []byte, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp := header.Get(TimestampHeader)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrStaleTimestamp, timestamp)
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return ErrStaleTimestamp
+	}
+	want := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(header.Get(SignatureHeader)), []byte(want)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+func (o *Observer) deliver(ep *endpoint, e observer.Event, body []byte) Delivery {
+	d := Delivery{URL: ep.url, Event: e}
+	if !ep.allow(time.Now(), o.cooldown()) {
+		d.Err = ErrCircuitOpen
+		return d
+	}
+
+	attempts := o.Retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	for d.Attempts < attempts {
+		if d.Attempts > 0 && o.Retry.Backoff != nil {
+			time.Sleep(o.Retry.Backoff(d.Attempts))
+		}
+		d.Attempts++
+		var retry bool
+		d.StatusCode, retry, d.Err = o.post(ep.url, e, body)
+		if d.Err == nil || !retry {
+			break
+		}
+	}
+	ep.done(d, time.Now(), o.failureThreshold())
+	return d
+}
+
+// post выполняет один запрос и сообщает, имеет ли смысл его повторить.
+func (o *Observer) post(url string, e observer.Event, body []byte) (code int, retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	// Каждая попытка подписывается заново, со своей меткой времени.
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(o.Secret, timestamp, body))
+	req.Header.Set(EventIDHeader, strconv.FormatUint(e.Seq, 10))
+
+	resp, err := o.client().Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	// Дочитываем тело, чтобы соединение можно было переиспользовать.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp.StatusCode, false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return resp.StatusCode, true, fmt.Errorf("ответ %s", resp.Status)
+	default:
+		// Остальные ответы говорят об ошибке в самом запросе, повтор не поможет.
+		return resp.StatusCode, false, fmt.Errorf("ответ %s", resp.Status)
+	}
+}
+
+func (o *Observer) client() *http.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	return defaultClient
+}
+
+func (o *Observer) failureThreshold() int {
+	if o.FailureThreshold > 0 {
+		return o.FailureThreshold
+	}
+	return DefaultFailureThreshold
+}
+
+func (o *Observer) cooldown() time.Duration {
+	if o.Cooldown > 0 {
+		return o.Cooldown
+	}
+	return DefaultCooldown
+}
+
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// endpoint - конечная точка и состояние ее выключателя.
+type endpoint struct {
+	url string
+
+	mu       sync.Mutex
+	status   Status
+	openedAt time.Time
+	open     bool
+	// probing - выполняется пробная доставка после Cooldown.
+	probing bool
+}
+
+// state возвращает состояние выключателя в момент now. Вызывается под ep.mu.
+func (ep *endpoint) state(now time.Time, cooldown time.Duration) State {
+	switch {
+	case !ep.open:
+		return Closed
+	case ep.probing || now.Sub(ep.openedAt) >= cooldown:
+		return HalfOpen
+	default:
+		return Open
+	}
+}
+
+// allow сообщает, можно ли доставлять событие. После Cooldown пропускает
+// ровно одну пробную доставку, остальные ждут ее результата.
+func (ep *endpoint) allow(now time.Time, cooldown time.Duration) bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	switch ep.state(now, cooldown) {
+	case Closed:
+		return true
+	case HalfOpen:
+		if ep.probing {
+			return false
+		}
+		ep.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+// done учитывает результат доставки и переключает выключатель.
+func (ep *endpoint) done(d Delivery, now time.Time, threshold int) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.status.LastDelivery = now
+	ep.status.LastError = d.Err
+	if d.Err == nil {
+		ep.status.Delivered++
+		ep.status.ConsecutiveFailures = 0
+		ep.open = false
+		ep.probing = false
+		return
+	}
+	ep.status.Failed++
+	ep.status.ConsecutiveFailures++
+	if ep.probing || ep.status.ConsecutiveFailures >= threshold {
+		ep.open = true
+		ep.openedAt = now
+		ep.probing = false
+	}
+}
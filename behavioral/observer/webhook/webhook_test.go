@@ -0,0 +1,236 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/A1esandr/golang-design-patterns/behavioral/observer"
+)
+
+var secret = []byte("секрет")
+
+// receiver - тестовая конечная точка, отвечающая кодами из очереди responses,
+// а когда очередь пуста - 200.
+type receiver struct {
+	t *testing.T
+
+	mu        sync.Mutex
+	responses []int
+	requests  []*http.Request
+	bodies    [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rc.t.Error(err)
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	code := http.StatusOK
+	if len(rc.responses) > 0 {
+		code, rc.responses = rc.responses[0], rc.responses[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func (rc *receiver) calls() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func (rc *receiver) respond(codes ...int) {
+	rc.mu.Lock()
+	rc.responses = append(rc.responses, codes...)
+	rc.mu.Unlock()
+}
+
+// newTestObserver возвращает Observer для новой тестовой конечной точки с быстрыми повторами.
+func newTestObserver(t *testing.T) (*Observer, *receiver) {
+	rc := &receiver{t: t}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+	o := New(secret, srv.URL)
+	o.Retry.Backoff = observer.ExponentialBackoff(time.Millisecond, 4*time.Millisecond)
+	return o, rc
+}
+
+func TestSignedPayload(t *testing.T) {
+	o, rc := newTestObserver(t)
+	now := time.Now()
+	if err := o.Deliver(observer.Event{Seq: 3, Time: now, Data: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, body := rc.requests[0], rc.bodies[0]
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("запрос %s с Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+	}
+	if r.Header.Get(EventIDHeader) != "3" {
+		t.Fatalf("%s = %q", EventIDHeader, r.Header.Get(EventIDHeader))
+	}
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Seq != 3 || p.Data != 42 || !p.Time.Equal(now) {
+		t.Fatalf("тело %s", body)
+	}
+
+	// Подпись проверяется так же, как это сделал бы получатель.
+	want := Sign(secret, r.Header.Get(TimestampHeader), body)
+	if !hmac.Equal([]byte(r.Header.Get(SignatureHeader)), []byte(want)) {
+		t.Fatalf("подпись %q, ожидалась %q", r.Header.Get(SignatureHeader), want)
+	}
+	if err := Verify(secret, r.Header, body, time.Minute); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	body := []byte(`{"seq":1}`)
+	fresh := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	header := func(timestamp, signature string) http.Header {
+		h := http.Header{}
+		h.Set(TimestampHeader, timestamp)
+		h.Set(SignatureHeader, signature)
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{"верный", header(fresh, Sign(secret, fresh, body)), body, nil},
+		{"другой ключ", header(fresh, Sign([]byte("другой"), fresh, body)), body, ErrBadSignature},
+		{"измененное тело", header(fresh, Sign(secret, fresh, body)), []byte(`{"seq":2}`), ErrBadSignature},
+		{"подмененная метка", header(fresh, Sign(secret, stale, body)), body, ErrBadSignature},
+		{"повтор старого запроса", header(stale, Sign(secret, stale, body)), body, ErrStaleTimestamp},
+		{"без метки", header("", Sign(secret, "", body)), body, ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(secret, tt.header, tt.body, 5*time.Minute); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, ожидалась %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	o, rc := newTestObserver(t)
+	var deliveries []Delivery
+	o.OnDelivery = func(d Delivery) { deliveries = append(deliveries, d) }
+	rc.respond(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+
+	if err := o.Deliver(observer.Event{Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if rc.calls() != 3 {
+		t.Fatalf("выполнено %d запросов, ожидалось 3", rc.calls())
+	}
+	if d := deliveries[0]; d.Attempts != 3 || d.StatusCode != http.StatusOK || d.Err != nil {
+		t.Fatalf("доставка %+v", d)
+	}
+	// Каждая попытка подписана заново.
+	for i, r := range rc.requests {
+		if err := Verify(secret, r.Header, rc.bodies[i], time.Minute); err != nil {
+			t.Fatalf("попытка %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestNoRetryClientErrors(t *testing.T) {
+	o, rc := newTestObserver(t)
+	rc.respond(http.StatusBadRequest)
+
+	err := o.Deliver(observer.Event{Seq: 1})
+	if err == nil {
+		t.Fatal("ответ 400 принят как успешная доставка")
+	}
+	if rc.calls() != 1 {
+		t.Fatalf("после ответа 400 выполнено %d запросов, ожидался 1", rc.calls())
+	}
+	if s := o.Status()[0]; s.Failed != 1 || s.LastError == nil {
+		t.Fatalf("статус %+v", s)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	o, rc := newTestObserver(t)
+	o.Retry.Attempts = 1
+	o.FailureThreshold = 2
+	o.Cooldown = 30 * time.Millisecond
+	state := func() State { return o.Status()[0].State }
+
+	rc.respond(500, 500, 500)
+	o.OnNotify(observer.Event{Seq: 1})
+	if state() != Closed {
+		t.Fatalf("после одной неудачи состояние %v", state())
+	}
+	o.OnNotify(observer.Event{Seq: 2})
+	if state() != Open {
+		t.Fatalf("после двух неудач подряд состояние %v", state())
+	}
+
+	// Пока выключатель разомкнут, запросы не отправляются.
+	if err := o.Deliver(observer.Event{Seq: 3}); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Deliver = %v, ожидалась ErrCircuitOpen", err)
+	}
+	if rc.calls() != 2 {
+		t.Fatalf("выполнено %d запросов, ожидалось 2", rc.calls())
+	}
+
+	// После Cooldown пробная доставка неудачна - выключатель снова размыкается.
+	time.Sleep(o.Cooldown)
+	if state() != HalfOpen {
+		t.Fatalf("после Cooldown состояние %v", state())
+	}
+	o.OnNotify(observer.Event{Seq: 4})
+	if state() != Open || rc.calls() != 3 {
+		t.Fatalf("после неудачной пробы состояние %v, запросов %d", state(), rc.calls())
+	}
+
+	// Успешная проба замыкает выключатель.
+	time.Sleep(o.Cooldown)
+	if err := o.Deliver(observer.Event{Seq: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if s := o.Status()[0]; s.State != Closed || s.ConsecutiveFailures != 0 || s.Delivered != 1 || s.Failed != 3 {
+		t.Fatalf("статус после успешной пробы %+v", s)
+	}
+}
+
+func TestWithErrors(t *testing.T) {
+	o, rc := newTestObserver(t)
+	o.Retry.Attempts = 1
+	rc.respond(http.StatusBadRequest)
+
+	var dead []*observer.DeliveryError
+	n := observer.NewReliable(observer.RetryPolicy{Attempts: 2}, func(_ observer.Event, err *observer.DeliveryError) {
+		dead = append(dead, err)
+	})
+	n.Register(o.WithErrors())
+
+	// Первая попытка получает 400, повтор ReliableNotifier - 200.
+	if err := n.Notify(observer.Event{Seq: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if rc.calls() != 2 || len(dead) != 0 {
+		t.Fatalf("запросов %d, в DeadLetter %d", rc.calls(), len(dead))
+	}
+}
@@ -0,0 +1,51 @@
+// Программа digest принимает в качестве аргумента каталог и печатает значения дайджеста
+// для каждого обычного файла в этом каталоге, отсортированные по имени пути.
+//
+// Флаг -impl выбирает реализацию MD5All: serial, parallel или bounded.
+package main
+
+import (
+	"crypto/md5"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines/digest/bounded"
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines/digest/parallel"
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines/digest/serial"
+)
+
+var impls = map[string]func(string) (map[string][md5.Size]byte, error){
+	"serial":   serial.MD5All,
+	"parallel": parallel.MD5All,
+	"bounded":  bounded.MD5All,
+}
+
+func main() {
+	impl := flag.String("impl", "bounded", "реализация MD5All: serial, parallel или bounded")
+	flag.Parse()
+	md5All, ok := impls[*impl]
+	if !ok || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Рассчитать MD5 сумму всех файлов
+	// в указанном каталоге,
+	// затем печатаем результаты,
+	// отсортированные по имени пути.
+	m, err := md5All(flag.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var paths []string
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Printf("%x  %s\n", m[path], path)
+	}
+}
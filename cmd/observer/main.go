@@ -0,0 +1,48 @@
+// Программа observer служит примером приложения, использующего паттерн Наблюдатель (Observer).
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/A1esandr/golang-design-patterns/behavioral/observer"
+)
+
+type eventObserver struct {
+	id int
+}
+
+func (o *eventObserver) OnNotify(e observer.Event) {
+	fmt.Printf("*** Наблюдатель %d получил: %d\n", o.id, e.Data)
+}
+
+func main() {
+	// Инициализируем новый Notifier.
+	n := observer.New()
+
+	// Регистрируем пару наблюдателей.
+	n.Register(&eventObserver{id: 1})
+	n.Register(&eventObserver{id: 2})
+
+	// Наблюдатель-функция и подписка через канал.
+	n.Register(observer.ObserverFunc(func(e observer.Event) {
+		fmt.Printf("*** Функция получила: %d\n", e.Data)
+	}))
+	events, unsubscribe := n.Subscribe(context.Background())
+	defer unsubscribe()
+
+	// Простой цикл, публикующий текущий Unix timestamp наблюдателям.
+	stop := time.NewTimer(10 * time.Second).C
+	tick := time.NewTicker(time.Second).C
+	for {
+		select {
+		case <-stop:
+			return
+		case t := <-tick:
+			n.Notify(observer.Event{Data: t.UnixNano()})
+		case e := <-events:
+			fmt.Printf("*** Подписка получила: %d\n", e.Data)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines/earlystop/byclose"
+)
+
+// Пример ранней остановки вышестоящих этапов, посредством закрытия канала
+func main() {
+	// Установливаем done канал, общий для всего пайплайна,
+	// и закрываем этот канал при выходе из этого пайплайна
+	// в качестве сигнала для всех go-процедур,
+	// что мы начали выходить.
+	done := make(chan struct{})
+	defer close(done)
+
+	in := byclose.Gen(done, 2, 3)
+
+	// Распределяем работу sq по двум goroutine,
+	// которые обе читают из in.
+	c1 := byclose.Sq(done, in)
+	c2 := byclose.Sq(done, in)
+
+	// Используем первое значение из output.
+	out := byclose.Merge(done, c1, c2)
+	fmt.Println(<-out) // 4 or 9
+
+	// done будет закрыт отложенным вызовом.
+}
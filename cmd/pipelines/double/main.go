@@ -2,29 +2,9 @@ package main
 
 import (
 	"fmt"
-)
-
-func gen(nums ...int) <-chan int {
-	out := make(chan int)
-	go func() {
-		for _, n := range nums {
-			out <- n
-		}
-		close(out)
-	}()
-	return out
-}
 
-func sq(in <-chan int) <-chan int {
-	out := make(chan int)
-	go func() {
-		for n := range in {
-			out <- n * n
-		}
-		close(out)
-	}()
-	return out
-}
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines"
+)
 
 // Пример простого пайплайна из 3 этапов
 // 1 этап - gen - принимает значения и передает
@@ -34,7 +14,7 @@ func sq(in <-chan int) <-chan int {
 // мы можем составить его друг в друга любое количество раз.
 func main() {
 	// Устанавливаем пайплайн и потребляем вывод.
-	for n := range sq(sq(gen(2, 3))) {
+	for n := range pipelines.Sq(pipelines.Sq(pipelines.Gen(2, 3))) {
 		fmt.Println(n) // 16 затем 81
 	}
 }
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines"
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines/earlystop"
+)
+
+// Пример ранней остановки - остановка вышестоящих этапов
+func main() {
+	in := earlystop.Gen(2, 3)
+
+	// Распределяем работу sq по двум goroutine,
+	// которые обе читают из in.
+	c1 := pipelines.Sq(in)
+	c2 := pipelines.Sq(in)
+
+	// Используем первое значение из вывода.
+	done := make(chan struct{}, 2)
+	out := earlystop.Merge(done, c1, c2)
+	fmt.Println(<-out) // 4 или 9
+
+	// Сообщаем оставшимся отправителям, что мы уходим.
+	done <- struct{}{}
+	done <- struct{}{}
+}
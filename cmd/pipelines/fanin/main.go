@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines"
+)
+
+// Пример fan in - функция merge считывает данные с нескольких входов и работает до тех пор,
+// пока все они не будут закрыты, путем мультиплексирования входных каналов в один канал,
+// который закрыт, когда все входы закрыты.
+func main() {
+	in := pipelines.Gen(2, 3)
+
+	// Распределяем работу sq по двум goroutine,
+	// которые обе читают из in.
+	c1 := pipelines.Sq(in)
+	c2 := pipelines.Sq(in)
+
+	// Потребляем объединенный вывод из c1 и c2.
+	for n := range pipelines.Merge(c1, c2) {
+		fmt.Println(n) // 4 затем 9, или 9 затем 4
+	}
+}
@@ -2,29 +2,9 @@ package main
 
 import (
 	"fmt"
-)
-
-func gen(nums ...int) <-chan int {
-	out := make(chan int)
-	go func() {
-		for _, n := range nums {
-			out <- n
-		}
-		close(out)
-	}()
-	return out
-}
 
-func sq(in <-chan int) <-chan int {
-	out := make(chan int)
-	go func() {
-		for n := range in {
-			out <- n * n
-		}
-		close(out)
-	}()
-	return out
-}
+	"github.com/A1esandr/golang-design-patterns/concurrency/pipelines"
+)
 
 // Пример простого пайплайна из 3 этапов
 // 1 этап - gen - принимает значения и передает
@@ -32,8 +12,8 @@ func sq(in <-chan int) <-chan int {
 // 3 этап - main - принимает результаты и использует
 func main() {
 	// Устанавливаем пайплайн.
-	c := gen(2, 3)
-	out := sq(c)
+	c := pipelines.Gen(2, 3)
+	out := pipelines.Sq(c)
 
 	// Потребляем вывод.
 	fmt.Println(<-out) // 4
@@ -20,8 +20,8 @@ import (
 	"net/http"
 	"time"
 
-	"./google"
-	"./userip"
+	"github.com/A1esandr/golang-design-patterns/concurrency/context/google"
+	"github.com/A1esandr/golang-design-patterns/concurrency/context/userip"
 )
 
 func main() {
@@ -0,0 +1,43 @@
+// Программа semaphore демонстрирует использование пакета semaphore.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/A1esandr/golang-design-patterns/semaphore"
+)
+
+func main() {
+	fmt.Println("Начало")
+	tickets, timeout := 1, 3*time.Second
+	s := semaphore.New(tickets, timeout)
+
+	if err := s.Acquire(); err != nil {
+		panic(err)
+	}
+
+	// Пробуем повторно захватить семафор
+	// Захват должен потерпеть неудачу
+	if err := s.Acquire(); err != nil {
+		fmt.Println("Повторно захватить семафор не удалось")
+		fmt.Println(err.Error())
+	}
+
+	// Пробуем захватить семафор с контекстом, срок которого истекает раньше таймаута.
+	// Ошибка оборачивает ctx.Err() и отличается от ErrNoTickets.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.AcquireContext(ctx); errors.Is(err, context.DeadlineExceeded) {
+		fmt.Println(err.Error())
+	}
+
+	// Выполняем важную работу
+
+	if err := s.Release(); err != nil {
+		panic(err)
+	}
+	fmt.Println("Завершение")
+}
@@ -9,7 +9,7 @@ import (
 	"encoding/json"
 	"net/http"
 
-	"../userip"
+	"github.com/A1esandr/golang-design-patterns/concurrency/context/userip"
 )
 
 // Results это упорядоченный список результатов поиска.
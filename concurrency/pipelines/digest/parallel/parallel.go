@@ -1,13 +1,13 @@
-package main
+// Пакет parallel вычисляет MD5 суммы файлов двухступенчатым пайплайном,
+// запуская отдельную goroutine для каждого файла.
+package parallel
 
 import (
 	"crypto/md5"
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"sort"
 	"sync"
 )
 
@@ -92,23 +92,3 @@ func MD5All(root string) (map[string][md5.Size]byte, error) {
 	}
 	return m, nil
 }
-
-func main() {
-	// Рассчитать MD5 сумму всех файлов
-	// в указанном каталоге,
-	// затем печатаем результаты,
-	// отсортированные по имени пути.
-	m, err := MD5All(os.Args[1])
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	var paths []string
-	for path := range m {
-		paths = append(paths, path)
-	}
-	sort.Strings(paths)
-	for _, path := range paths {
-		fmt.Printf("%x  %s\n", m[path], path)
-	}
-}
@@ -1,12 +1,11 @@
-package main
+// Пакет serial вычисляет MD5 суммы файлов последовательно, без конкурентности.
+package serial
 
 import (
 	"crypto/md5"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"sort"
 )
 
 // MD5All читает все файлы в дереве файлов с корнем в root и возвращает карту
@@ -34,23 +33,3 @@ func MD5All(root string) (map[string][md5.Size]byte, error) {
 	}
 	return m, nil
 }
-
-func main() {
-	// Рассчитать MD5 сумму всех файлов
-	// в указанном каталоге,
-	// затем печатаем результаты,
-	// отсортированные по имени пути.
-	m, err := MD5All(os.Args[1])
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	var paths []string
-	for path := range m {
-		paths = append(paths, path)
-	}
-	sort.Strings(paths)
-	for _, path := range paths {
-		fmt.Printf("%x  %s\n", m[path], path)
-	}
-}
@@ -0,0 +1,72 @@
+// Пакет byclose предоставляет этапы пайплайна, которые останавливаются
+// при закрытии общего для всего пайплайна канала done.
+package byclose
+
+import "sync"
+
+// Gen отправляет nums в возвращаемый канал, пока не будет закрыт done.
+func Gen(done <-chan struct{}, nums ...int) <-chan int {
+	out := make(chan int, len(nums))
+	go func() {
+		for _, n := range nums {
+			select {
+			case out <- n:
+			case <-done:
+				return
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
+// Sq отправляет квадраты значений из in, пока in или done не будут закрыты.
+func Sq(done <-chan struct{}, in <-chan int) <-chan int {
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		for n := range in {
+			select {
+			case out <- n * n:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Merge объединяет cs в один канал, пока все cs или done не будут закрыты.
+func Merge(done <-chan struct{}, cs ...<-chan int) <-chan int {
+	var wg sync.WaitGroup
+	out := make(chan int)
+
+	// Запускаем output goroutine
+	// для каждого входного канала в cs.
+	// output копирует значения из c в out
+	// до закрытия c или done,
+	// затем вызывает wg.Done.
+	output := func(c <-chan int) {
+		defer wg.Done()
+		for n := range c {
+			select {
+			case out <- n:
+			case <-done:
+				return
+			}
+		}
+	}
+	wg.Add(len(cs))
+	for _, c := range cs {
+		go output(c)
+	}
+
+	// Запускаем goroutine чтобы закрыть out
+	// когда все output goroutine заверешены.
+	// Это должно начнаться после вызова wg.Add.
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -1,11 +1,13 @@
-package main
+// Пакет earlystop предоставляет этапы пайплайна, поддерживающие раннюю остановку:
+// получатель может перестать читать вывод, не оставляя заблокированных отправителей.
+package earlystop
 
-import (
-	"fmt"
-	"sync"
-)
+import "sync"
 
-func gen(nums ...int) <-chan int {
+// Gen отправляет nums в буферизованный канал и закрывает его.
+// Буфер вмещает все значения, поэтому Gen не блокируется,
+// даже если получатель прочитает не все.
+func Gen(nums ...int) <-chan int {
 	out := make(chan int, len(nums))
 	go func() {
 		for _, n := range nums {
@@ -16,18 +18,10 @@ func gen(nums ...int) <-chan int {
 	return out
 }
 
-func sq(in <-chan int) <-chan int {
-	out := make(chan int)
-	go func() {
-		for n := range in {
-			out <- n * n
-		}
-		close(out)
-	}()
-	return out
-}
-
-func merge(done <-chan struct{}, cs ...<-chan int) <-chan int {
+// Merge объединяет cs в один канал.
+// Каждое значение из done позволяет одной заблокированной output goroutine
+// отказаться от отправки текущего значения.
+func Merge(done <-chan struct{}, cs ...<-chan int) <-chan int {
 	var wg sync.WaitGroup
 	out := make(chan int)
 
@@ -59,22 +53,3 @@ func merge(done <-chan struct{}, cs ...<-chan int) <-chan int {
 	}()
 	return out
 }
-
-// Пример ранней остановки - остановка вышестоящих этапов
-func main() {
-	in := gen(2, 3)
-
-	// Распределяем работу sq по двум goroutine,
-	// которые обе читают из in.
-	c1 := sq(in)
-	c2 := sq(in)
-
-	// Используем первое значение из вывода.
-	done := make(chan struct{}, 2)
-	out := merge(done, c1, c2)
-	fmt.Println(<-out) // 4 или 9
-
-	// Сообщаем оставшимся отправителям, что мы уходим.
-	done <- struct{}{}
-	done <- struct{}{}
-}
@@ -1,11 +1,10 @@
-package main
+// Пакет pipelines предоставляет этапы простых пайплайнов на каналах.
+package pipelines
 
-import (
-	"fmt"
-	"sync"
-)
+import "sync"
 
-func gen(nums ...int) <-chan int {
+// Gen - первый этап пайплайна: отправляет nums в возвращаемый канал и закрывает его.
+func Gen(nums ...int) <-chan int {
 	out := make(chan int)
 	go func() {
 		for _, n := range nums {
@@ -16,7 +15,11 @@ func gen(nums ...int) <-chan int {
 	return out
 }
 
-func sq(in <-chan int) <-chan int {
+// Sq - промежуточный этап пайплайна: отправляет квадраты значений из in,
+// пока in не будет закрыт.
+// Поскольку Sq имеет одинаковый тип для входящих и исходящих каналов,
+// его можно составить друг в друга любое количество раз.
+func Sq(in <-chan int) <-chan int {
 	out := make(chan int)
 	go func() {
 		for n := range in {
@@ -27,7 +30,10 @@ func sq(in <-chan int) <-chan int {
 	return out
 }
 
-func merge(cs ...<-chan int) <-chan int {
+// Merge (fan in) считывает данные с нескольких входов и работает до тех пор,
+// пока все они не будут закрыты, путем мультиплексирования входных каналов в один канал,
+// который закрыт, когда все входы закрыты.
+func Merge(cs ...<-chan int) <-chan int {
 	var wg sync.WaitGroup
 	out := make(chan int)
 
@@ -55,20 +61,3 @@ func merge(cs ...<-chan int) <-chan int {
 	}()
 	return out
 }
-
-// Пример fan in - функция merge считывает данные с нескольких входов и работает до тех пор,
-// пока все они не будут закрыты, путем мультиплексирования входных каналов в один канал,
-// который закрыт, когда все входы закрыты.
-func main() {
-	in := gen(2, 3)
-
-	// Распределяем работу sq по двум goroutine,
-	// которые обе читают из in.
-	c1 := sq(in)
-	c2 := sq(in)
-
-	// Потребляем объединенный вывод из c1 и c2.
-	for n := range merge(c1, c2) {
-		fmt.Println(n) // 4 затем 9, или 9 затем 4
-	}
-}
@@ -0,0 +1,8 @@
+module github.com/A1esandr/golang-design-patterns
+
+go 1.21
+
+require (
+	github.com/gorilla/context v1.1.2
+	gopkg.in/tomb.v2 v2.0.0-20161208151619-d5d1b5820637
+)
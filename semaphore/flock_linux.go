@@ -0,0 +1,123 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// flockMinPoll и flockMaxPoll ограничивают интервал опроса занятых файлов блокировки.
+	flockMinPoll = time.Millisecond
+	flockMaxPoll = 50 * time.Millisecond
+)
+
+// flockSemaphore - межпроцессный семафор на tickets файлах блокировки.
+// Билет - это эксклюзивная блокировка flock на одном из файлов.
+// Ядро снимает блокировку при закрытии дескриптора, в том числе при падении процесса,
+// поэтому билеты умерших процессов освобождаются автоматически.
+type flockSemaphore struct {
+	dir     string
+	tickets int
+	timeout time.Duration
+
+	mu sync.Mutex
+	// held - стек файлов, блокировки которых удерживает этот процесс.
+	held []*os.File
+}
+
+// NewFlock создает семафор на tickets билетов, общий для всех процессов,
+// использующих тот же каталог dir. Каталог создается, если его нет.
+func NewFlock(dir string, tickets int, timeout time.Duration) (Semaphore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("не могу создать каталог семафора: %w", err)
+	}
+	return &flockSemaphore{
+		dir:     dir,
+		tickets: tickets,
+		timeout: timeout,
+	}, nil
+}
+
+func (s *flockSemaphore) Acquire() error {
+	return acquireTimeout(s.timeout, s.AcquireContext)
+}
+
+// AcquireContext перебирает файлы блокировки, пока не захватит свободный,
+// и опрашивает их с растущим интервалом, пока не истечет ctx.
+// С уже отмененным ctx билет не захватывается, даже если он свободен.
+func (s *flockSemaphore) AcquireContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("захват семафора прерван: %w", err)
+	}
+	poll := flockMinPoll
+	timer := time.NewTimer(poll)
+	defer timer.Stop()
+	for {
+		ok, err := s.tryAcquire()
+		if err != nil || ok {
+			return err
+		}
+
+		timer.Reset(poll)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("захват семафора прерван: %w", ctx.Err())
+		case <-timer.C:
+		}
+		if poll *= 2; poll > flockMaxPoll {
+			poll = flockMaxPoll
+		}
+	}
+}
+
+// Release освобождает последний захваченный этим процессом билет.
+// Если процесс не удерживает ни одного билета, сразу возвращает ErrIllegalRelease.
+func (s *flockSemaphore) Release() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.held) == 0 {
+		return ErrIllegalRelease
+	}
+	f := s.held[len(s.held)-1]
+	s.held = s.held[:len(s.held)-1]
+	// Закрытие дескриптора снимает блокировку flock.
+	return f.Close()
+}
+
+// ReleaseContext освобождает билет. Освобождение не ждет,
+// поэтому ctx учитывается только если он уже отменен.
+func (s *flockSemaphore) ReleaseContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("освобождение семафора прервано: %w", err)
+	}
+	return s.Release()
+}
+
+// tryAcquire однократно пытается заблокировать любой свободный файл.
+func (s *flockSemaphore) tryAcquire() (bool, error) {
+	for i := 0; i < s.tickets; i++ {
+		name := filepath.Join(s.dir, fmt.Sprintf("ticket-%d.lock", i))
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0o644)
+		if err != nil {
+			return false, fmt.Errorf("не могу открыть файл семафора: %w", err)
+		}
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			s.mu.Lock()
+			s.held = append(s.held, f)
+			s.mu.Unlock()
+			return true, nil
+		}
+		f.Close()
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, fmt.Errorf("не могу заблокировать файл семафора: %w", err)
+		}
+	}
+	return false, nil
+}
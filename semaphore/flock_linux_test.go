@@ -0,0 +1,26 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlockAcquireContextCancelled(t *testing.T) {
+	s, err := NewFlock(t.TempDir(), 1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.AcquireContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("AcquireContext с отмененным ctx = %v, ожидалась context.Canceled", err)
+	}
+	if err := s.Acquire(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
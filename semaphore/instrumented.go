@@ -0,0 +1,154 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+// waitBuckets - верхние границы интервалов гистограммы времени ожидания.
+// Ожидания дольше последней границы попадают в последний, открытый интервал.
+var waitBuckets = [...]time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// Instrumented - обертка над Semaphore, собирающая статистику его использования.
+type Instrumented struct {
+	sem Semaphore
+
+	holders      atomic.Int64
+	waiters      atomic.Int64
+	acquisitions atomic.Uint64
+	timeouts     atomic.Uint64
+	canceled     atomic.Uint64
+	// waits[i] - число ожиданий не дольше waitBuckets[i],
+	// последний элемент - число ожиданий дольше всех границ.
+	waits   [len(waitBuckets) + 1]atomic.Uint64
+	waitSum atomic.Int64
+}
+
+// Stats - снимок статистики семафора.
+type Stats struct {
+	// Holders - число захваченных и еще не освобожденных билетов.
+	Holders int64
+	// Waiters - число вызовов, ожидающих билет прямо сейчас.
+	Waiters int64
+	// Acquisitions - общее число успешных захватов.
+	Acquisitions uint64
+	// Timeouts - число захватов, завершившихся ErrNoTickets.
+	Timeouts uint64
+	// Canceled - число захватов, прерванных контекстом.
+	Canceled uint64
+	// Wait - распределение времени ожидания всех попыток захвата.
+	Wait Histogram
+}
+
+// Histogram - гистограмма длительностей.
+// Counts[i] - число значений не больше Bounds[i],
+// последний элемент Counts - число значений больше всех границ.
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Sum    time.Duration
+}
+
+// NewInstrumented возвращает семафор, который делегирует вызовы s и собирает статистику.
+func NewInstrumented(s Semaphore) *Instrumented {
+	return &Instrumented{sem: s}
+}
+
+func (s *Instrumented) Acquire() error {
+	return s.observe(s.sem.Acquire)
+}
+
+func (s *Instrumented) AcquireContext(ctx context.Context) error {
+	return s.observe(func() error {
+		return s.sem.AcquireContext(ctx)
+	})
+}
+
+func (s *Instrumented) Release() error {
+	err := s.sem.Release()
+	if err == nil {
+		s.holders.Add(-1)
+	}
+	return err
+}
+
+func (s *Instrumented) ReleaseContext(ctx context.Context) error {
+	err := s.sem.ReleaseContext(ctx)
+	if err == nil {
+		s.holders.Add(-1)
+	}
+	return err
+}
+
+// Snapshot возвращает текущую статистику семафора.
+// Счетчики читаются по отдельности, поэтому при конкурентной работе
+// снимок может быть не строго согласован между полями.
+func (s *Instrumented) Snapshot() Stats {
+	h := Histogram{
+		Bounds: append([]time.Duration(nil), waitBuckets[:]...),
+		Counts: make([]uint64, len(s.waits)),
+		Sum:    time.Duration(s.waitSum.Load()),
+	}
+	for i := range s.waits {
+		h.Counts[i] = s.waits[i].Load()
+	}
+	return Stats{
+		Holders:      s.holders.Load(),
+		Waiters:      s.waiters.Load(),
+		Acquisitions: s.acquisitions.Load(),
+		Timeouts:     s.timeouts.Load(),
+		Canceled:     s.canceled.Load(),
+		Wait:         h,
+	}
+}
+
+// Publish публикует статистику семафора через expvar под именем name,
+// после чего ее можно смотреть на /debug/vars работающего процесса.
+// Как и expvar.Publish, паникует, если имя уже занято.
+func (s *Instrumented) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return s.Snapshot()
+	}))
+}
+
+func (s *Instrumented) observe(acquire func() error) error {
+	s.waiters.Add(1)
+	start := time.Now()
+	err := acquire()
+	wait := time.Since(start)
+	s.waiters.Add(-1)
+
+	s.waitSum.Add(int64(wait))
+	s.waits[bucket(wait)].Add(1)
+	switch {
+	case err == nil:
+		s.holders.Add(1)
+		s.acquisitions.Add(1)
+	case errors.Is(err, ErrNoTickets):
+		s.timeouts.Add(1)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		s.canceled.Add(1)
+	}
+	return err
+}
+
+// bucket возвращает индекс интервала гистограммы для длительности d.
+func bucket(d time.Duration) int {
+	for i, b := range waitBuckets {
+		if d <= b {
+			return i
+		}
+	}
+	return len(waitBuckets)
+}
@@ -0,0 +1,109 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Keyed - семафор, ограничивающий число одновременных захватов по каждому ключу
+// (например, по удаленному хосту или IP-адресу пользователя) и общее число захватов.
+// Состояние ключа хранится, только пока по нему есть захваты или ожидающие,
+// поэтому память не растет с появлением новых ключей.
+type Keyed struct {
+	perKey  int64
+	timeout time.Duration
+	global  *Weighted
+
+	mu   sync.Mutex
+	keys map[string]*keyEntry
+}
+
+// keyEntry - семафор одного ключа и число его пользователей:
+// удерживающих билет и ожидающих его.
+type keyEntry struct {
+	sem  *Weighted
+	refs int
+}
+
+// NewKeyed создает семафор на perKey билетов для каждого ключа и global билетов на все ключи.
+func NewKeyed(perKey, global int, timeout time.Duration) *Keyed {
+	return &Keyed{
+		perKey:  int64(perKey),
+		timeout: timeout,
+		global:  NewWeighted(int64(global), timeout),
+		keys:    make(map[string]*keyEntry),
+	}
+}
+
+// Acquire захватывает билет для key, ожидая не дольше таймаута, заданного в NewKeyed.
+func (s *Keyed) Acquire(key string) error {
+	return acquireTimeout(s.timeout, func(ctx context.Context) error {
+		return s.AcquireContext(ctx, key)
+	})
+}
+
+// AcquireContext захватывает билет для key, ожидая не дольше, чем позволяет ctx.
+// Сначала захватывается билет ключа и только потом общий,
+// чтобы ожидание по занятому ключу не удерживало общий билет.
+// С уже отмененным ctx билет не захватывается, даже если он свободен.
+func (s *Keyed) AcquireContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("захват семафора прерван: %w", err)
+	}
+	e := s.ref(key)
+	if err := e.sem.AcquireContext(ctx); err != nil {
+		s.unref(key, e)
+		return err
+	}
+	if err := s.global.AcquireContext(ctx); err != nil {
+		e.sem.Release()
+		s.unref(key, e)
+		return err
+	}
+	return nil
+}
+
+// Release освобождает билет, захваченный для key.
+func (s *Keyed) Release(key string) error {
+	s.mu.Lock()
+	e, ok := s.keys[key]
+	s.mu.Unlock()
+	if !ok {
+		return ErrIllegalRelease
+	}
+	if err := e.sem.Release(); err != nil {
+		return err
+	}
+	s.unref(key, e)
+	return s.global.Release()
+}
+
+// Len возвращает число ключей, по которым сейчас есть захваты или ожидающие.
+func (s *Keyed) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
+
+func (s *Keyed) ref(key string) *keyEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.keys[key]
+	if !ok {
+		e = &keyEntry{sem: NewWeighted(s.perKey, s.timeout)}
+		s.keys[key] = e
+	}
+	e.refs++
+	return e
+}
+
+// unref удаляет состояние ключа, когда им больше никто не пользуется.
+func (s *Keyed) unref(key string, e *keyEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e.refs--; e.refs == 0 {
+		delete(s.keys, key)
+	}
+}
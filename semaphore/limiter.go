@@ -0,0 +1,168 @@
+package semaphore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock - источник времени для Limiter.
+// Подмена часов позволяет детерминированно проверять ограничитель без реального ожидания.
+// Сроки context.Context часы не затрагивают (см. Wait).
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock - часы на основе пакета time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Limiter - ограничитель пропускной способности по алгоритму token bucket:
+// в ведро емкостью burst поступает rate токенов в секунду, каждая операция тратит один токен.
+// В отличие от Semaphore, токены не возвращаются - они восстанавливаются со временем.
+type Limiter struct {
+	rate  float64
+	burst int
+	clock Clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// Reservation - зарезервированный токен, которым можно воспользоваться через Delay.
+type Reservation struct {
+	l    *Limiter
+	ok   bool
+	act  time.Time
+	done bool
+}
+
+// NewLimiter создает ограничитель на rate операций в секунду с запасом burst.
+// Ведро изначально заполнено.
+func NewLimiter(rate float64, burst int) *Limiter {
+	return NewLimiterClock(rate, burst, systemClock{})
+}
+
+// NewLimiterClock создает ограничитель, получающий время от clock.
+func NewLimiterClock(rate float64, burst int, clock Clock) *Limiter {
+	return &Limiter{
+		rate:   rate,
+		burst:  burst,
+		clock:  clock,
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// Allow сообщает, можно ли выполнить операцию прямо сейчас, и если да - тратит токен.
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(l.clock.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Reserve резервирует токен и возвращает Reservation, Delay которого
+// показывает, сколько нужно подождать перед выполнением операции.
+// Если токен никогда не поступит, OK резервирования возвращает false.
+func (l *Limiter) Reserve() *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	l.advance(now)
+	if l.tokens < 1 && l.rate <= 0 {
+		return &Reservation{l: l}
+	}
+	l.tokens--
+	r := &Reservation{l: l, ok: true, act: now}
+	if l.tokens < 0 {
+		r.act = now.Add(time.Duration(-l.tokens / l.rate * float64(time.Second)))
+	}
+	return r
+}
+
+// Wait ждет токен, пока позволяет ctx.
+// Если срок ctx истечет раньше, чем поступит токен, Wait возвращает ErrNoTickets сразу, не дожидаясь срока.
+//
+// Срок ctx всегда измеряется реальным временем, а задержка - часами ограничителя.
+// С подмененными часами эта проверка зависит от реального времени, поэтому
+// в детерминированных проверках используйте ctx без срока и отменяйте его явно.
+func (l *Limiter) Wait(ctx context.Context) error {
+	r := l.Reserve()
+	if !r.OK() {
+		return ErrNoTickets
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		r.Cancel()
+		return ErrNoTickets
+	}
+	select {
+	case <-l.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return fmt.Errorf("ожидание токена прервано: %w", ctx.Err())
+	}
+}
+
+// advance пополняет ведро токенами, поступившими к моменту now. Вызывается под l.mu.
+func (l *Limiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+}
+
+// OK сообщает, удалось ли зарезервировать токен.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay возвращает время, которое нужно подождать перед выполнением операции.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return 0
+	}
+	if d := r.act.Sub(r.l.clock.Now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel отказывается от резервирования и возвращает токен в ведро,
+// если время выполнения операции еще не наступило.
+// Повторные вызовы ничего не делают.
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+	l := r.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	if r.done || !r.act.After(now) {
+		return
+	}
+	r.done = true
+	l.advance(now)
+	l.tokens++
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+}
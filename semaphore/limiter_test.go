@@ -0,0 +1,209 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock - часы, время которых двигается только через Advance.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeTimer{at: c.now.Add(d), c: ch})
+	return ch
+}
+
+// Advance сдвигает время на d и срабатывает наступившие таймеры.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = waiters
+}
+
+// timers возвращает число ожидающих таймеров.
+func (c *fakeClock) timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+func TestLimiterAllow(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiterClock(10, 3, clock)
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("Allow %d: запас burst исчерпан раньше времени", i)
+		}
+	}
+	if l.Allow() {
+		t.Fatal("Allow сверх burst")
+	}
+
+	// При 10 токенах в секунду один токен поступает за 100мс.
+	clock.Advance(99 * time.Millisecond)
+	if l.Allow() {
+		t.Fatal("токен поступил раньше срока")
+	}
+	clock.Advance(time.Millisecond)
+	if !l.Allow() {
+		t.Fatal("токен не поступил через 100мс")
+	}
+}
+
+func TestLimiterBurstRefill(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiterClock(10, 3, clock)
+	for l.Allow() {
+	}
+
+	// За час поступило бы 36000 токенов, но ведро вмещает только burst.
+	clock.Advance(time.Hour)
+	n := 0
+	for l.Allow() {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("после долгого простоя доступно %d токенов, ожидалось 3", n)
+	}
+}
+
+func TestLimiterReserveDelay(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiterClock(2, 1, clock)
+
+	tests := []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond}
+	for i, want := range tests {
+		r := l.Reserve()
+		if !r.OK() {
+			t.Fatalf("резервирование %d не удалось", i)
+		}
+		if got := r.Delay(); got != want {
+			t.Errorf("Delay резервирования %d = %v, ожидалось %v", i, got, want)
+		}
+	}
+
+	clock.Advance(time.Second)
+	if got := l.Reserve().Delay(); got != time.Second {
+		t.Errorf("Delay после сдвига часов = %v, ожидалось 1s", got)
+	}
+}
+
+func TestLimiterReserveZeroRate(t *testing.T) {
+	l := NewLimiterClock(0, 1, newFakeClock())
+	if !l.Reserve().OK() {
+		t.Fatal("токен из начального запаса не зарезервирован")
+	}
+	if l.Reserve().OK() {
+		t.Fatal("при нулевой скорости токен не может поступить")
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiterClock(1, 1, clock)
+	l.Reserve()
+
+	r := l.Reserve()
+	if got := r.Delay(); got != time.Second {
+		t.Fatalf("Delay = %v, ожидалось 1s", got)
+	}
+	r.Cancel()
+	r.Cancel() // Повторная отмена ничего не меняет.
+
+	// Возвращенный токен снова можно зарезервировать с той же задержкой.
+	if got := l.Reserve().Delay(); got != time.Second {
+		t.Fatalf("Delay после Cancel = %v, ожидалось 1s", got)
+	}
+
+	// Резервирование, время которого наступило, отменить нельзя.
+	r = l.Reserve()
+	clock.Advance(2 * time.Second)
+	r.Cancel()
+	if l.Allow() {
+		t.Fatal("Cancel вернул токен уже наступившего резервирования")
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiterClock(1, 1, clock)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(context.Background()) }()
+	for clock.timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Wait завершился до поступления токена: %v", err)
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimiterWaitCancel(t *testing.T) {
+	clock := newFakeClock()
+	l := NewLimiterClock(1, 1, clock)
+	l.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx) }()
+	for clock.timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, ожидалась context.Canceled", err)
+	}
+
+	// Отмененное ожидание вернуло токен: следующий поступит через секунду, а не через две.
+	if got := l.Reserve().Delay(); got != time.Second {
+		t.Fatalf("Delay после отмены Wait = %v, ожидалось 1s", got)
+	}
+}
@@ -0,0 +1,125 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrPermitReleased = errors.New("разрешение семафора уже освобождено")
+	ErrForeignPermit  = errors.New("разрешение выдано другим семафором")
+)
+
+// Tracked - семафор, который при захвате выдает разрешение (Permit).
+// Освободить билет можно только через выданное разрешение, поэтому
+// лишний Release не может ни зависнуть, ни освободить чужой билет.
+type Tracked struct {
+	sem   *Weighted
+	debug bool
+
+	mu   sync.Mutex
+	held map[*Permit]struct{}
+}
+
+// Permit - разрешение на один билет семафора Tracked.
+type Permit struct {
+	owner    *Tracked
+	released atomic.Bool
+	acquired time.Time
+	stack    []byte
+}
+
+// PermitInfo описывает удерживаемое разрешение.
+type PermitInfo struct {
+	Acquired time.Time
+	// Stack - стек вызова, захватившего разрешение.
+	// Заполняется только в отладочном режиме.
+	Stack string
+}
+
+// NewTracked создает семафор на tickets билетов.
+// Если debug равен true, для каждого разрешения запоминается стек захватившего его вызова,
+// что позволяет найти место утечки через Held.
+func NewTracked(tickets int, timeout time.Duration, debug bool) *Tracked {
+	return &Tracked{
+		sem:   NewWeighted(int64(tickets), timeout),
+		debug: debug,
+		held:  make(map[*Permit]struct{}),
+	}
+}
+
+// Acquire захватывает билет, ожидая не дольше таймаута, заданного в NewTracked.
+func (s *Tracked) Acquire() (*Permit, error) {
+	if err := s.sem.Acquire(); err != nil {
+		return nil, err
+	}
+	return s.issue(), nil
+}
+
+// AcquireContext захватывает билет, ожидая не дольше, чем позволяет ctx.
+func (s *Tracked) AcquireContext(ctx context.Context) (*Permit, error) {
+	if err := s.sem.AcquireContext(ctx); err != nil {
+		return nil, err
+	}
+	return s.issue(), nil
+}
+
+// Release освобождает билет, удерживаемый разрешением p.
+// В отличие от p.Release проверяет, что p выдано именно этим семафором.
+func (s *Tracked) Release(p *Permit) error {
+	if p == nil {
+		return ErrIllegalRelease
+	}
+	if p.owner != s {
+		return ErrForeignPermit
+	}
+	return p.Release()
+}
+
+// Held возвращает удерживаемые разрешения, начиная с самого старого.
+// Разрешения, удерживаемые дольше ожидаемого, скорее всего утекли.
+func (s *Tracked) Held() []PermitInfo {
+	s.mu.Lock()
+	infos := make([]PermitInfo, 0, len(s.held))
+	for p := range s.held {
+		infos = append(infos, PermitInfo{Acquired: p.acquired, Stack: string(p.stack)})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Acquired.Before(infos[j].Acquired)
+	})
+	return infos
+}
+
+func (s *Tracked) issue() *Permit {
+	p := &Permit{owner: s, acquired: time.Now()}
+	if s.debug {
+		p.stack = debug.Stack()
+	}
+	s.mu.Lock()
+	s.held[p] = struct{}{}
+	s.mu.Unlock()
+	return p
+}
+
+// Release возвращает билет семафору.
+// Повторный вызов не меняет состояние семафора и возвращает ErrPermitReleased.
+func (p *Permit) Release() error {
+	if p == nil || p.owner == nil {
+		return ErrIllegalRelease
+	}
+	if !p.released.CompareAndSwap(false, true) {
+		return ErrPermitReleased
+	}
+	s := p.owner
+	s.mu.Lock()
+	delete(s.held, p)
+	s.mu.Unlock()
+	return s.sem.ReleaseN(1)
+}
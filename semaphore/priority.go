@@ -0,0 +1,198 @@
+package semaphore
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultPriority - приоритет захвата через методы интерфейса Semaphore.
+const DefaultPriority = 0
+
+// Priority - семафор, в котором освободившийся билет достается ожидающему
+// с наибольшим приоритетом, а при равных приоритетах - тому, кто ждет дольше.
+//
+// Если задан интервал старения aging, приоритет ожидающего растет на единицу
+// за каждый интервал aging ожидания, поэтому низкоприоритетные запросы
+// со временем все равно будут обслужены.
+type Priority struct {
+	tickets int
+	timeout time.Duration
+	aging   time.Duration
+	start   time.Time
+
+	mu      sync.Mutex
+	cur     int
+	seq     uint64
+	waiters priorityQueue
+}
+
+// NewPriority создает семафор на tickets билетов.
+// aging равный нулю отключает старение приоритетов.
+func NewPriority(tickets int, timeout, aging time.Duration) *Priority {
+	return &Priority{
+		tickets: tickets,
+		timeout: timeout,
+		aging:   aging,
+		start:   time.Now(),
+	}
+}
+
+// AcquirePriority захватывает билет с приоритетом priority, ожидая не дольше, чем позволяет ctx.
+// Чем больше priority, тем раньше запрос получит билет.
+// С уже отмененным ctx билет не захватывается, даже если он свободен.
+func (s *Priority) AcquirePriority(ctx context.Context, priority int) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("захват семафора прерван: %w", err)
+	}
+	s.mu.Lock()
+	if s.cur < s.tickets && s.waiters.Len() == 0 {
+		s.cur++
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.seq++
+	w := &priorityWaiter{
+		priority: priority,
+		enqueued: time.Since(s.start),
+		seq:      s.seq,
+		aging:    s.aging,
+		ready:    make(chan struct{}),
+	}
+	heap.Push(&s.waiters, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// Билет был выдан одновременно с отменой - возвращаем его.
+			s.cur--
+			s.notifyWaiters()
+		default:
+			heap.Remove(&s.waiters, w.index)
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("захват семафора прерван: %w", ctx.Err())
+	}
+}
+
+// Acquire захватывает билет с приоритетом по умолчанию,
+// ожидая не дольше таймаута, заданного в NewPriority.
+func (s *Priority) Acquire() error {
+	return acquireTimeout(s.timeout, s.AcquireContext)
+}
+
+func (s *Priority) AcquireContext(ctx context.Context) error {
+	return s.AcquirePriority(ctx, DefaultPriority)
+}
+
+// Release освобождает билет и отдает его ожидающему с наибольшим приоритетом.
+func (s *Priority) Release() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cur == 0 {
+		return ErrIllegalRelease
+	}
+	s.cur--
+	s.notifyWaiters()
+	return nil
+}
+
+// ReleaseContext освобождает билет. Освобождение не ждет,
+// поэтому ctx учитывается только если он уже отменен.
+func (s *Priority) ReleaseContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("освобождение семафора прервано: %w", err)
+	}
+	return s.Release()
+}
+
+// notifyWaiters раздает свободные билеты ожидающим. Вызывается под s.mu.
+func (s *Priority) notifyWaiters() {
+	for s.cur < s.tickets && s.waiters.Len() > 0 {
+		w := heap.Pop(&s.waiters).(*priorityWaiter)
+		s.cur++
+		close(w.ready)
+	}
+}
+
+type priorityWaiter struct {
+	priority int
+	// enqueued - момент постановки в очередь относительно создания семафора.
+	enqueued time.Duration
+	seq      uint64
+	aging    time.Duration
+	index    int
+	ready    chan struct{}
+}
+
+// before сообщает, должен ли w получить билет раньше o.
+func (w *priorityWaiter) before(o *priorityWaiter) bool {
+	if w.aging > 0 {
+		// Эффективный приоритет в момент now равен priority + (now-enqueued)/aging.
+		// Слагаемое now/aging у всех одинаково, поэтому сравниваем priority - enqueued/aging,
+		// и порядок в куче не меняется со временем. Частное enqueued/aging делим на целую часть q
+		// и остаток r: сначала сравниваем priority - q, затем меньший остаток идет раньше.
+		// Умножение priority на aging переполнялось бы при больших приоритетах.
+		wq, wr := w.enqueued/w.aging, w.enqueued%w.aging
+		oq, or := o.enqueued/o.aging, o.enqueued%o.aging
+		// w.priority - wq > o.priority - oq равносильно w.priority + oq > o.priority + wq.
+		if c := compareSums(int64(w.priority), int64(oq), int64(o.priority), int64(wq)); c != 0 {
+			return c > 0
+		}
+		if wr != or {
+			return wr < or
+		}
+	} else if w.priority != o.priority {
+		return w.priority > o.priority
+	}
+	return w.seq < o.seq
+}
+
+// compareSums сравнивает a+b с c+d без переполнения и возвращает -1, 0 или 1.
+// Сумма двух int64 представлена половиной, округленной вниз, и младшим битом.
+func compareSums(a, b, c, d int64) int {
+	h1 := a>>1 + b>>1 + a&b&1
+	h2 := c>>1 + d>>1 + c&d&1
+	switch {
+	case h1 > h2:
+		return 1
+	case h1 < h2:
+		return -1
+	}
+	return int((a^b)&1) - int((c^d)&1)
+}
+
+// priorityQueue реализует heap.Interface над ожидающими.
+type priorityQueue []*priorityWaiter
+
+func (q priorityQueue) Len() int           { return len(q) }
+func (q priorityQueue) Less(i, j int) bool { return q[i].before(q[j]) }
+
+func (q priorityQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *priorityQueue) Push(x any) {
+	w := x.(*priorityWaiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return w
+}
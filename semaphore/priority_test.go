@@ -0,0 +1,131 @@
+package semaphore
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPriorityWaiterBefore(t *testing.T) {
+	const aging = time.Second
+	waiter := func(priority int, enqueued time.Duration, seq uint64) *priorityWaiter {
+		return &priorityWaiter{priority: priority, enqueued: enqueued, seq: seq, aging: aging}
+	}
+	tests := []struct {
+		name string
+		w, o *priorityWaiter
+		want bool
+	}{
+		{"больший приоритет", waiter(2, 0, 2), waiter(1, 0, 1), true},
+		{"равный приоритет - раньше пришедший", waiter(1, 0, 1), waiter(1, 0, 2), true},
+		{"старение догоняет приоритет", waiter(0, 0, 1), waiter(2, 3*aging, 2), true},
+		{"старение еще не догнало", waiter(0, 0, 1), waiter(2, aging, 2), false},
+		{"доли интервала старения", waiter(0, 0, 1), waiter(1, aging+time.Millisecond, 2), true},
+		{"большой приоритет", waiter(1<<40, time.Hour, 2), waiter(0, 0, 1), true},
+		{"крайние приоритеты", waiter(math.MaxInt, 0, 2), waiter(math.MinInt, 0, 1), true},
+		{"крайние приоритеты наоборот", waiter(math.MinInt, 0, 1), waiter(math.MaxInt, 0, 2), false},
+		{"поздний приход при большом enqueued", waiter(0, math.MaxInt64, 2), waiter(-9_000_000_000, 0, 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.w.before(tt.o); got != tt.want {
+				t.Fatalf("before = %v, ожидалось %v", got, tt.want)
+			}
+			if tt.want && tt.o.before(tt.w) {
+				t.Fatal("порядок несимметричен")
+			}
+		})
+	}
+}
+
+func TestCompareSums(t *testing.T) {
+	tests := []struct {
+		a, b, c, d int64
+		want       int
+	}{
+		{1, 2, 2, 1, 0},
+		{1, 3, 2, 1, 1},
+		{-1, -2, -2, 0, -1},
+		{math.MaxInt64, math.MaxInt64, math.MaxInt64, math.MaxInt64 - 1, 1},
+		{math.MinInt64, math.MinInt64, math.MinInt64, math.MinInt64 + 1, -1},
+		{math.MaxInt64, math.MinInt64, -1, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := compareSums(tt.a, tt.b, tt.c, tt.d); got != tt.want {
+			t.Errorf("compareSums(%d, %d, %d, %d) = %d, ожидалось %d", tt.a, tt.b, tt.c, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPriorityOrder(t *testing.T) {
+	s := NewPriority(1, time.Second, 0)
+	if err := s.Acquire(); err != nil {
+		t.Fatal(err)
+	}
+	order := make(chan int, 3)
+	for i, priority := range []int{1, 1 << 40, 2} {
+		go func(priority int) {
+			if err := s.AcquirePriority(context.Background(), priority); err != nil {
+				t.Error(err)
+			}
+			order <- priority
+		}(priority)
+		waitPriorityQueued(t, s, i+1)
+	}
+	for _, want := range []int{1 << 40, 2, 1} {
+		s.Release()
+		if got := <-order; got != want {
+			t.Fatalf("билет получил приоритет %d, ожидался %d", got, want)
+		}
+	}
+	s.Release()
+}
+
+func TestPriorityAging(t *testing.T) {
+	const aging = 10 * time.Millisecond
+	s := NewPriority(1, time.Second, aging)
+	if err := s.Acquire(); err != nil {
+		t.Fatal(err)
+	}
+	order := make(chan int, 2)
+	acquire := func(priority int) {
+		if err := s.AcquirePriority(context.Background(), priority); err != nil {
+			t.Error(err)
+		}
+		order <- priority
+	}
+
+	go acquire(0)
+	waitPriorityQueued(t, s, 1)
+	// За пять интервалов старения приоритет первого ожидающего вырос примерно до 5.
+	time.Sleep(5 * aging)
+	go acquire(2)
+	waitPriorityQueued(t, s, 2)
+
+	s.Release()
+	if got := <-order; got != 0 {
+		t.Fatalf("первым обслужен приоритет %d, ожидался долго ждавший с приоритетом 0", got)
+	}
+	s.Release()
+	<-order
+	s.Release()
+}
+
+// waitPriorityQueued ждет, пока в очереди s окажется n ожидающих.
+func waitPriorityQueued(t *testing.T, s *Priority, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		l := s.waiters.Len()
+		s.mu.Unlock()
+		if l == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("в очереди %d ожидающих, ожидалось %d", l, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,142 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RW - семафор чтения-записи: до size читателей могут удерживать билеты одновременно,
+// а писатель захватывает всю емкость сразу.
+// Ожидающие писатели имеют преимущество: пока писатель ждет, новые читатели не допускаются,
+// поэтому поток читателей не может заморить писателя голодом.
+type RW struct {
+	size    int64
+	timeout time.Duration
+
+	mu      sync.Mutex
+	readers int64
+	writer  bool
+	// readerQ и writerQ - очереди ожидающих, элементы - каналы, закрываемые при выдаче билета.
+	readerQ list.List
+	writerQ list.List
+}
+
+// NewRW создает семафор чтения-записи на size читателей.
+func NewRW(size int, timeout time.Duration) *RW {
+	return &RW{size: int64(size), timeout: timeout}
+}
+
+// AcquireRead захватывает билет читателя, ожидая не дольше таймаута, заданного в NewRW.
+func (s *RW) AcquireRead() error {
+	return acquireTimeout(s.timeout, s.AcquireReadContext)
+}
+
+// AcquireWrite захватывает семафор для записи, ожидая не дольше таймаута, заданного в NewRW.
+func (s *RW) AcquireWrite() error {
+	return acquireTimeout(s.timeout, s.AcquireWriteContext)
+}
+
+// AcquireReadContext захватывает билет читателя, ожидая не дольше, чем позволяет ctx.
+// С уже отмененным ctx ничего не захватывается, даже если семафор свободен.
+func (s *RW) AcquireReadContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("захват семафора прерван: %w", err)
+	}
+	s.mu.Lock()
+	if !s.writer && s.writerQ.Len() == 0 && s.readerQ.Len() == 0 && s.readers < s.size {
+		s.readers++
+		s.mu.Unlock()
+		return nil
+	}
+	return s.wait(ctx, &s.readerQ, func() { s.readers-- })
+}
+
+// AcquireWriteContext захватывает семафор для записи, ожидая не дольше, чем позволяет ctx.
+// С уже отмененным ctx ничего не захватывается, даже если семафор свободен.
+func (s *RW) AcquireWriteContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("захват семафора прерван: %w", err)
+	}
+	s.mu.Lock()
+	if !s.writer && s.readers == 0 && s.writerQ.Len() == 0 {
+		s.writer = true
+		s.mu.Unlock()
+		return nil
+	}
+	return s.wait(ctx, &s.writerQ, func() { s.writer = false })
+}
+
+// ReleaseRead освобождает билет читателя.
+func (s *RW) ReleaseRead() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readers == 0 {
+		return ErrIllegalRelease
+	}
+	s.readers--
+	s.notifyWaiters()
+	return nil
+}
+
+// ReleaseWrite освобождает семафор, захваченный для записи.
+func (s *RW) ReleaseWrite() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.writer {
+		return ErrIllegalRelease
+	}
+	s.writer = false
+	s.notifyWaiters()
+	return nil
+}
+
+// wait ставит вызывающего в очередь q и ждет выдачи билета или отмены ctx.
+// Вызывается под s.mu и освобождает его. undo возвращает билет,
+// если он был выдан одновременно с отменой.
+func (s *RW) wait(ctx context.Context, q *list.List, undo func()) error {
+	ready := make(chan struct{})
+	elem := q.PushBack(ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			undo()
+		default:
+			q.Remove(elem)
+		}
+		// Ушедший из очереди писатель мог задерживать читателей.
+		s.notifyWaiters()
+		s.mu.Unlock()
+		return fmt.Errorf("захват семафора прерван: %w", ctx.Err())
+	}
+}
+
+// notifyWaiters выдает билеты ожидающим, отдавая предпочтение писателям. Вызывается под s.mu.
+func (s *RW) notifyWaiters() {
+	if s.writer {
+		return
+	}
+	if front := s.writerQ.Front(); front != nil {
+		if s.readers == 0 {
+			s.writer = true
+			close(s.writerQ.Remove(front).(chan struct{}))
+		}
+		return
+	}
+	for s.readers < s.size {
+		front := s.readerQ.Front()
+		if front == nil {
+			return
+		}
+		s.readers++
+		close(s.readerQ.Remove(front).(chan struct{}))
+	}
+}
@@ -0,0 +1,90 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRWMutualExclusion(t *testing.T) {
+	const size = 4
+	s := NewRW(size, time.Second)
+	var readers, writers atomic.Int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(writer bool) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if writer {
+					if err := s.AcquireWrite(); err != nil {
+						t.Error(err)
+						return
+					}
+					if w := writers.Add(1); w != 1 {
+						t.Errorf("одновременно %d писателей", w)
+					}
+					if r := readers.Load(); r != 0 {
+						t.Errorf("писатель работает вместе с %d читателями", r)
+					}
+					writers.Add(-1)
+					s.ReleaseWrite()
+					continue
+				}
+				if err := s.AcquireRead(); err != nil {
+					t.Error(err)
+					return
+				}
+				if r := readers.Add(1); r > size {
+					t.Errorf("одновременно %d читателей при емкости %d", r, size)
+				}
+				if w := writers.Load(); w != 0 {
+					t.Error("читатель работает вместе с писателем")
+				}
+				readers.Add(-1)
+				s.ReleaseRead()
+			}
+		}(i%4 == 0)
+	}
+	wg.Wait()
+}
+
+func TestRWWriterNotStarved(t *testing.T) {
+	s := NewRW(4, time.Second)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	// Читатели приходят непрерывно, так что емкость ни на миг не освобождается полностью,
+	// если новые читатели не уступают ожидающему писателю.
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if err := s.AcquireRead(); err != nil {
+					t.Error(err)
+					return
+				}
+				time.Sleep(100 * time.Microsecond)
+				s.ReleaseRead()
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if err := s.AcquireWriteContext(ctx); err != nil {
+		t.Fatalf("писатель не дождался билета среди потока читателей: %v", err)
+	}
+	s.ReleaseWrite()
+	close(stop)
+	wg.Wait()
+}
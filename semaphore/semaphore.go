@@ -1,8 +1,11 @@
-package main
+// Пакет semaphore предоставляет реализации паттерна Семафор.
+package semaphore
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -15,6 +18,11 @@ var (
 type Semaphore interface {
 	Acquire() error
 	Release() error
+	// AcquireContext захватывает семафор, ожидая не дольше, чем позволяет ctx.
+	// При отмене или истечении срока ctx возвращается ошибка, оборачивающая ctx.Err().
+	AcquireContext(ctx context.Context) error
+	// ReleaseContext освобождает семафор, ожидая не дольше, чем позволяет ctx.
+	ReleaseContext(ctx context.Context) error
 }
 
 type implementation struct {
@@ -22,51 +30,120 @@ type implementation struct {
 	timeout time.Duration
 }
 
+// timers хранит остановленные таймеры для повторного использования,
+// чтобы медленный путь захвата и освобождения не создавал новый таймер на каждый вызов.
+var timers sync.Pool
+
+func getTimer(d time.Duration) *time.Timer {
+	if t, ok := timers.Get().(*time.Timer); ok {
+		t.Reset(d)
+		return t
+	}
+	return time.NewTimer(d)
+}
+
+func putTimer(t *time.Timer) {
+	if !t.Stop() {
+		// Таймер сработал: вычитываем значение, если его никто не получил,
+		// чтобы следующий пользователь не увидел устаревшее срабатывание.
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	timers.Put(t)
+}
+
+// Acquire захватывает семафор, ожидая не дольше таймаута, заданного в New.
+// Свободный билет захватывается без обращения к таймерам.
 func (s *implementation) Acquire() error {
 	select {
 	case s.sem <- struct{}{}:
 		return nil
-	case <-time.After(s.timeout):
+	default:
+	}
+
+	t := getTimer(s.timeout)
+	defer putTimer(t)
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-t.C:
 		return ErrNoTickets
 	}
 }
 
+// Release освобождает семафор, ожидая не дольше таймаута, заданного в New.
 func (s *implementation) Release() error {
 	select {
-	case _ = <-s.sem:
+	case <-s.sem:
+		return nil
+	default:
+	}
+
+	t := getTimer(s.timeout)
+	defer putTimer(t)
+	select {
+	case <-s.sem:
 		return nil
-	case <-time.After(s.timeout):
+	case <-t.C:
 		return ErrIllegalRelease
 	}
 }
 
-func New(tickets int, timeout time.Duration) Semaphore {
-	return &implementation{
-		sem:     make(chan struct{}, tickets),
-		timeout: timeout,
+// AcquireContext захватывает семафор, ожидая не дольше, чем позволяет ctx.
+// С уже отмененным ctx билет не захватывается, даже если он свободен.
+func (s *implementation) AcquireContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("захват семафора прерван: %w", err)
+	}
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	default:
 	}
-}
 
-func main() {
-	fmt.Println("Начало")
-	tickets, timeout := 1, 3*time.Second
-	s := New(tickets, timeout)
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("захват семафора прерван: %w", ctx.Err())
+	}
+}
 
-	if err := s.Acquire(); err != nil {
-		panic(err)
+func (s *implementation) ReleaseContext(ctx context.Context) error {
+	select {
+	case <-s.sem:
+		return nil
+	default:
 	}
 
-	// Пробуем повторно захватить семафор
-	// Захват должен потерпеть неудачу
-	if err := s.Acquire(); err != nil {
-		fmt.Println("Повторно захватить семафор не удалось")
-		fmt.Println(err.Error())
+	select {
+	case <-s.sem:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("освобождение семафора прервано: %w", ctx.Err())
 	}
+}
 
-	// Выполняем важную работу
+// acquireTimeout вызывает acquire с контекстом, истекающим через timeout,
+// и заменяет истечение срока на ErrNoTickets. Через него методы без context.Context
+// реализуются с помощью своих вариантов с контекстом.
+func acquireTimeout(timeout time.Duration, acquire func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := acquire(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrNoTickets
+	}
+	return err
+}
 
-	if err := s.Release(); err != nil {
-		panic(err)
+// New создает семафор на tickets билетов.
+// timeout ограничивает ожидание в методах, не принимающих context.Context.
+func New(tickets int, timeout time.Duration) Semaphore {
+	return &implementation{
+		sem:     make(chan struct{}, tickets),
+		timeout: timeout,
 	}
-	fmt.Println("Завершение")
 }
@@ -0,0 +1,102 @@
+package semaphore
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// afterSemaphore - прежняя реализация, создающая таймер через time.After
+// на каждый вызов. Оставлена для сравнения с быстрым путем New.
+type afterSemaphore struct {
+	sem     chan struct{}
+	timeout time.Duration
+}
+
+func (s *afterSemaphore) Acquire() error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-time.After(s.timeout):
+		return ErrNoTickets
+	}
+}
+
+func (s *afterSemaphore) Release() error {
+	select {
+	case <-s.sem:
+		return nil
+	case <-time.After(s.timeout):
+		return ErrIllegalRelease
+	}
+}
+
+type acquireReleaser interface {
+	Acquire() error
+	Release() error
+}
+
+var benchImpls = []struct {
+	name string
+	new  func(tickets int) acquireReleaser
+}{
+	{"pooled", func(tickets int) acquireReleaser { return New(tickets, time.Second) }},
+	{"timeAfter", func(tickets int) acquireReleaser {
+		return &afterSemaphore{sem: make(chan struct{}, tickets), timeout: time.Second}
+	}},
+}
+
+func benchmarkAcquireRelease(b *testing.B, s acquireReleaser) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := s.Acquire(); err != nil {
+				b.Error(err)
+				return
+			}
+			if err := s.Release(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkUncontended - один goroutine, билет всегда свободен.
+func BenchmarkUncontended(b *testing.B) {
+	for _, impl := range benchImpls {
+		b.Run(impl.name, func(b *testing.B) {
+			s := impl.new(1)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := s.Acquire(); err != nil {
+					b.Fatal(err)
+				}
+				if err := s.Release(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkModerateContention - билетов вдвое меньше, чем goroutine.
+func BenchmarkModerateContention(b *testing.B) {
+	procs := runtime.GOMAXPROCS(0)
+	for _, impl := range benchImpls {
+		b.Run(impl.name, func(b *testing.B) {
+			b.SetParallelism(2)
+			benchmarkAcquireRelease(b, impl.new(procs))
+		})
+	}
+}
+
+// BenchmarkHeavyContention - один билет на множество goroutine.
+func BenchmarkHeavyContention(b *testing.B) {
+	for _, impl := range benchImpls {
+		b.Run(impl.name, func(b *testing.B) {
+			b.SetParallelism(8)
+			benchmarkAcquireRelease(b, impl.new(1))
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireContextCancelled(t *testing.T) {
+	keyed := NewKeyed(1, 1, time.Second)
+	rwRead, rwWrite := NewRW(1, time.Second), NewRW(1, time.Second)
+	tests := []struct {
+		name    string
+		acquire func(context.Context) error
+	}{
+		{"New", New(1, time.Second).AcquireContext},
+		{"Weighted", NewWeighted(1, time.Second).AcquireContext},
+		{"Priority", NewPriority(1, time.Second, 0).AcquireContext},
+		{"Keyed", func(ctx context.Context) error { return keyed.AcquireContext(ctx, "ключ") }},
+		{"RW.Read", rwRead.AcquireReadContext},
+		{"RW.Write", rwWrite.AcquireWriteContext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err := tt.acquire(ctx); !errors.Is(err, context.Canceled) {
+				t.Fatalf("AcquireContext с отмененным ctx = %v, ожидалась context.Canceled", err)
+			}
+			// Свободный билет не должен быть занят отмененным вызовом.
+			ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			if err := tt.acquire(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTooManyTickets возвращается, если запрошено больше билетов, чем вообще есть в семафоре.
+	ErrTooManyTickets = errors.New("запрошено больше билетов, чем есть в семафоре")
+	// ErrInvalidTickets возвращается, если запрошено или возвращено неположительное число билетов.
+	ErrInvalidTickets = errors.New("число билетов должно быть больше нуля")
+)
+
+// Weighted - семафор, позволяющий захватывать сразу несколько билетов.
+// Ожидающие обслуживаются строго в порядке очереди (FIFO), поэтому крупный запрос
+// в начале очереди не будет обойден потоком мелких запросов.
+// Для захвата одного билета Weighted реализует интерфейс Semaphore.
+// Емкость семафора можно менять во время работы с помощью Resize.
+type Weighted struct {
+	size    int64
+	cur     int64
+	timeout time.Duration
+	mu      sync.Mutex
+	waiters list.List
+}
+
+// waiter - ожидающий в очереди запрос на n билетов.
+// Канал ready закрывается, когда билеты выданы или запрос отклонен;
+// во втором случае err содержит причину.
+type waiter struct {
+	n     int64
+	ready chan struct{}
+	err   error
+}
+
+// NewWeighted создает семафор на size билетов.
+// timeout используется методами, не принимающими context.Context.
+func NewWeighted(size int64, timeout time.Duration) *Weighted {
+	return &Weighted{size: size, timeout: timeout}
+}
+
+// AcquireN захватывает n билетов, ожидая не дольше таймаута, заданного в NewWeighted.
+func (s *Weighted) AcquireN(n int64) error {
+	return acquireTimeout(s.timeout, func(ctx context.Context) error {
+		return s.AcquireNContext(ctx, n)
+	})
+}
+
+// AcquireNContext захватывает n билетов, ожидая не дольше, чем позволяет ctx.
+// С уже отмененным ctx билеты не захватываются, даже если они свободны.
+// Запрос встает в конец очереди, даже если свободных билетов достаточно,
+// но перед ним уже кто-то ждет.
+func (s *Weighted) AcquireNContext(ctx context.Context, n int64) error {
+	if n <= 0 {
+		return ErrInvalidTickets
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("захват семафора прерван: %w", err)
+	}
+	s.mu.Lock()
+	if n > s.size {
+		s.mu.Unlock()
+		return ErrTooManyTickets
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	w := &waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return w.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			if w.err != nil {
+				s.mu.Unlock()
+				return w.err
+			}
+			// Билеты были выданы одновременно с отменой - возвращаем их.
+			s.cur -= n
+			s.notifyWaiters()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// Если мы были первыми в очереди, за нами могут быть запросы,
+			// которые уже можно удовлетворить.
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("захват семафора прерван: %w", ctx.Err())
+	}
+}
+
+// TryAcquireN захватывает n билетов без ожидания.
+// Возвращает false, если билетов недостаточно, очередь не пуста или n не больше нуля.
+func (s *Weighted) TryAcquireN(n int64) bool {
+	if n <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// ReleaseN возвращает n билетов и будит ожидающих в порядке очереди.
+func (s *Weighted) ReleaseN(n int64) error {
+	if n <= 0 {
+		return ErrInvalidTickets
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n > s.cur {
+		return ErrIllegalRelease
+	}
+	s.cur -= n
+	s.notifyWaiters()
+	return nil
+}
+
+func (s *Weighted) Acquire() error {
+	return s.AcquireN(1)
+}
+
+func (s *Weighted) Release() error {
+	return s.ReleaseN(1)
+}
+
+func (s *Weighted) AcquireContext(ctx context.Context) error {
+	return s.AcquireNContext(ctx, 1)
+}
+
+// ReleaseContext освобождает один билет. Освобождение у Weighted никогда не ждет,
+// поэтому ctx учитывается только если он уже отменен.
+func (s *Weighted) ReleaseContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("освобождение семафора прервано: %w", err)
+	}
+	return s.ReleaseN(1)
+}
+
+// Size возвращает текущую емкость семафора.
+func (s *Weighted) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// Resize меняет емкость семафора на size.
+// При увеличении ожидающие в очереди сразу получают освободившиеся билеты.
+// При уменьшении уже выданные билеты не отзываются: новые запросы ждут,
+// пока занятых билетов не станет меньше новой емкости.
+// Запросы, которые уже стоят в очереди и больше новой емкости, завершаются
+// с ErrTooManyTickets - так же, как такие запросы отклоняются при вызове.
+func (s *Weighted) Resize(size int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = size
+	for e := s.waiters.Front(); e != nil; {
+		next := e.Next()
+		if w := e.Value.(*waiter); w.n > size {
+			w.err = ErrTooManyTickets
+			s.waiters.Remove(e)
+			close(w.ready)
+		}
+		e = next
+	}
+	s.notifyWaiters()
+}
+
+// notifyWaiters выдает билеты ожидающим с начала очереди.
+// Останавливается на первом запросе, который нельзя удовлетворить,
+// чтобы не допустить его голодания. Вызывается под s.mu.
+func (s *Weighted) notifyWaiters() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(*waiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,177 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitQueued ждет, пока в очереди s окажется n запросов.
+func waitQueued(t *testing.T, s *Weighted, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.Lock()
+		l := s.waiters.Len()
+		s.mu.Unlock()
+		if l == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("в очереди %d запросов, ожидалось %d", l, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWeightedLargeRequestNotStarved(t *testing.T) {
+	s := NewWeighted(4, time.Second)
+	if err := s.AcquireN(3); err != nil {
+		t.Fatal(err)
+	}
+
+	large := make(chan error, 1)
+	go func() { large <- s.AcquireN(4) }()
+	waitQueued(t, s, 1)
+
+	// Поток мелких запросов: один билет свободен, но все они должны встать за крупным.
+	var small atomic.Int64
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+				if s.AcquireNContext(ctx, 1) == nil {
+					small.Add(1)
+					s.ReleaseN(1)
+				}
+				cancel()
+			}
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if got := small.Load(); got != 0 {
+		t.Fatalf("мелкие запросы обошли крупный %d раз", got)
+	}
+	if err := s.ReleaseN(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-large; err != nil {
+		t.Fatalf("крупный запрос: %v", err)
+	}
+	if err := s.ReleaseN(4); err != nil {
+		t.Fatal(err)
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestWeightedFIFOOrder(t *testing.T) {
+	s := NewWeighted(2, time.Second)
+	if err := s.AcquireN(2); err != nil {
+		t.Fatal(err)
+	}
+
+	order := make(chan int64, 3)
+	for i, n := range []int64{2, 1, 1} {
+		go func(n int64) {
+			if err := s.AcquireN(n); err != nil {
+				t.Error(err)
+			}
+			order <- n
+		}(n)
+		waitQueued(t, s, i+1)
+	}
+
+	s.ReleaseN(2)
+	if n := <-order; n != 2 {
+		t.Fatalf("первым обслужен запрос на %d билетов, ожидался запрос на 2", n)
+	}
+	s.ReleaseN(2)
+	<-order
+	<-order
+}
+
+func TestWeightedCancelRacesGrant(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		s := NewWeighted(1, time.Second)
+		if err := s.AcquireN(1); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- s.AcquireNContext(ctx, 1) }()
+		waitQueued(t, s, 1)
+
+		// Выдача и отмена происходят одновременно.
+		go s.ReleaseN(1)
+		cancel()
+		if err := <-done; err == nil {
+			s.ReleaseN(1)
+		} else if !errors.Is(err, context.Canceled) {
+			t.Fatalf("неожиданная ошибка: %v", err)
+		}
+
+		// Билет должен вернуться в семафор в любом исходе.
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		if err := s.AcquireNContext(ctx, 1); err != nil {
+			t.Fatalf("итерация %d: билет потерян: %v", i, err)
+		}
+		cancel()
+	}
+}
+
+func TestWeightedInvalidTickets(t *testing.T) {
+	s := NewWeighted(2, time.Second)
+	for _, n := range []int64{0, -1} {
+		if err := s.AcquireN(n); !errors.Is(err, ErrInvalidTickets) {
+			t.Errorf("AcquireN(%d) = %v, ожидалась ErrInvalidTickets", n, err)
+		}
+		if s.TryAcquireN(n) {
+			t.Errorf("TryAcquireN(%d) = true", n)
+		}
+		if err := s.ReleaseN(n); !errors.Is(err, ErrInvalidTickets) {
+			t.Errorf("ReleaseN(%d) = %v, ожидалась ErrInvalidTickets", n, err)
+		}
+	}
+	if !s.TryAcquireN(2) {
+		t.Fatal("неверные запросы изменили число занятых билетов")
+	}
+}
+
+func TestWeightedShrinkRejectsOversizedWaiters(t *testing.T) {
+	s := NewWeighted(4, time.Second)
+	if err := s.AcquireN(4); err != nil {
+		t.Fatal(err)
+	}
+	large := make(chan error, 1)
+	go func() { large <- s.AcquireN(3) }()
+	waitQueued(t, s, 1)
+
+	s.Resize(2)
+	if err := <-large; !errors.Is(err, ErrTooManyTickets) {
+		t.Fatalf("запрос больше новой емкости: %v, ожидалась ErrTooManyTickets", err)
+	}
+	if err := s.ReleaseN(4); err != nil {
+		t.Fatal(err)
+	}
+
+	// Отклоненный запрос не должен задерживать остальных.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.AcquireNContext(ctx, 1); err != nil {
+		t.Fatalf("захват после уменьшения емкости: %v", err)
+	}
+}